@@ -9,6 +9,7 @@ import (
 
 	cfg "github.com/conductorone/baton-metabase-v056/pkg/config"
 	"github.com/conductorone/baton-metabase-v056/pkg/connector"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/config"
 	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
 	"github.com/conductorone/baton-sdk/pkg/field"
@@ -59,5 +60,22 @@ func getConnector(ctx context.Context, config *cfg.MetabaseV056) (types.Connecto
 		return nil, err
 	}
 
-	return conn, nil
+	return &sessionClosingServer{ConnectorServer: conn, connector: cb}, nil
+}
+
+// sessionClosingServer ends the Metabase session of the connector when a sync is cleaned up. The connector
+// service process is stopped without notice afterwards, and the next request logs in again if needed.
+type sessionClosingServer struct {
+	types.ConnectorServer
+	connector *connector.Connector
+}
+
+func (s *sessionClosingServer) Cleanup(ctx context.Context, request *v2.ConnectorServiceCleanupRequest) (*v2.ConnectorServiceCleanupResponse, error) {
+	resp, err := s.ConnectorServer.Cleanup(ctx, request)
+
+	if closeErr := s.connector.Close(ctx); closeErr != nil {
+		ctxzap.Extract(ctx).Warn("failed to log out of Metabase", zap.Error(closeErr))
+	}
+
+	return resp, err
 }
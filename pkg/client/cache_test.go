@@ -0,0 +1,131 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newChangingTestServer serves the version setting and answers the requests to path with the body returned by
+// respond, which gets the number of requests to path so far. Bodies change on each request, so that a read served
+// from the HTTP cache returns the previous one.
+func newChangingTestServer(t *testing.T, path string, respond func(requests int) interface{}) *httptest.Server {
+	requests := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case getVersion:
+			_ = json.NewEncoder(w).Encode(VersionInfo{Tag: "v0.56.3"})
+		case path:
+			requests++
+			_ = json.NewEncoder(w).Encode(respond(requests))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// TestUncachedReads checks that the reads that provisioning decides on are never served from the HTTP cache.
+func TestUncachedReads(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		path    string
+		respond func(requests int) interface{}
+		read    func(c *MetabaseV056Client) (interface{}, error)
+	}{
+		{
+			name: "collection permission graph",
+			path: getCollectionGraph,
+			respond: func(requests int) interface{} {
+				return CollectionPermissionGraph{Revision: requests}
+			},
+			read: func(c *MetabaseV056Client) (interface{}, error) {
+				graph, _, err := c.GetCollectionPermissions(ctx)
+				if err != nil {
+					return nil, err
+				}
+				return graph.Revision, nil
+			},
+		},
+		{
+			name: "application permission graph",
+			path: applicationGraph,
+			respond: func(requests int) interface{} {
+				return ApplicationPermissionGraph{Revision: requests}
+			},
+			read: func(c *MetabaseV056Client) (interface{}, error) {
+				graph, _, err := c.GetApplicationPermissions(ctx)
+				if err != nil {
+					return nil, err
+				}
+				return graph.Revision, nil
+			},
+		},
+		{
+			name: "sandboxes",
+			path: sandboxes,
+			respond: func(requests int) interface{} {
+				return []*Sandbox{{ID: requests, GroupID: 3, TableID: 12}}
+			},
+			read: func(c *MetabaseV056Client) (interface{}, error) {
+				sandboxList, _, err := c.ListSandboxes(ctx, "12")
+				if err != nil {
+					return nil, err
+				}
+				return sandboxList[0].ID, nil
+			},
+		},
+		{
+			name: "users",
+			path: getUsers,
+			respond: func(requests int) interface{} {
+				return UsersAPIResponse{Data: []*User{{ID: 7, IsActive: requests == 1}}, Total: 1}
+			},
+			read: func(c *MetabaseV056Client) (interface{}, error) {
+				users, _, _, err := c.ListUsers(ctx, PageOptions{})
+				if err != nil {
+					return nil, err
+				}
+				return users[0].IsActive, nil
+			},
+		},
+		{
+			name: "user",
+			path: "/api/user/7",
+			respond: func(requests int) interface{} {
+				return User{ID: 7, IsSuperuser: requests > 1}
+			},
+			read: func(c *MetabaseV056Client) (interface{}, error) {
+				user, _, err := c.GetUser(ctx, "7")
+				if err != nil {
+					return nil, err
+				}
+				return user.IsSuperuser, nil
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newChangingTestServer(t, tt.path, tt.respond)
+			c, err := NewV056Client(ctx, server.URL, Credentials{APIKey: "some-api-key"}, false, RetryPolicy{})
+			require.NoError(t, err)
+
+			first, err := tt.read(c)
+			require.NoError(t, err)
+			second, err := tt.read(c)
+			require.NoError(t, err)
+
+			require.NotEqual(t, first, second)
+		})
+	}
+}
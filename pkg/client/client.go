@@ -3,12 +3,16 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/uhttp"
@@ -16,18 +20,88 @@ import (
 
 const (
 	// Headers.
-	headerAPIKey = "X-API-KEY"
+	headerAPIKey  = "X-API-KEY"
+	headerSession = "X-Metabase-Session"
 
 	// Endpoints.
 	// The permissions required for these endpoints to function correctly are determined by the group (administrators) attached to the creation of the API Key.
 	// For more information, please refer to docs-info.md or README.md.
 
+	// https://www.metabase.com/docs/latest/api#tag/apisession/post/api/session/
+	// https://www.metabase.com/docs/latest/api#tag/apisession/delete/api/session/
+	// Logging in with POST returns the session ID, logging out with DELETE ends the session of the request.
+	userSession = "/api/session"
+	/* Example JSON response version 0.56:
+	{
+	    "id": "38f4939c-ad7f-4cbe-ae54-30946daf8593"
+	}
+	*/
+
 	// https://www.metabase.com/docs/latest/api#tag/apisetting/get/api/setting/{key}
 	getVersion = "/api/setting/version"
 
 	// https://www.metabase.com/docs/latest/api#tag/apidatabase/get/api/database/
+	// Supports limit and offset, include=tables to return the tables of each database, and saved=true to also
+	// return the virtual database of saved questions.
 	getDatabases = "/api/database"
 
+	// https://www.metabase.com/docs/latest/api#tag/apiapi-key/get/api/api-key/
+	getAPIKeys = "/api/api-key"
+
+	// https://www.metabase.com/docs/latest/api#tag/apiapi-key/put/api/api-key/{id}/regenerate
+	regenerateAPIKey = "/api/api-key/%s/regenerate"
+
+	// https://www.metabase.com/docs/latest/api#tag/apiapi-key/delete/api/api-key/{id}
+	deleteAPIKey = "/api/api-key/%s"
+
+	// https://www.metabase.com/docs/latest/api#tag/apiuser/get/api/user/
+	getUsers = "/api/user"
+
+	// https://www.metabase.com/docs/latest/api#tag/apiuser/post/api/user/
+	createUser = "/api/user"
+
+	// https://www.metabase.com/docs/latest/api#tag/apiuser/get/api/user/{id}
+	// https://www.metabase.com/docs/latest/api#tag/apiuser/put/api/user/{id}
+	// https://www.metabase.com/docs/latest/api#tag/apiuser/delete/api/user/{id}
+	userByID = "/api/user/%s"
+
+	// https://www.metabase.com/docs/latest/api#tag/apiuser/put/api/user/{id}/reactivate
+	reactivateUser = "/api/user/%s/reactivate"
+
+	// https://www.metabase.com/docs/latest/api#tag/apipermissions/get/api/permissions/group
+	// https://www.metabase.com/docs/latest/api#tag/apipermissions/post/api/permissions/group
+	permissionGroups = "/api/permissions/group"
+
+	// https://www.metabase.com/docs/latest/api#tag/apipermissions/put/api/permissions/group/{group-id}
+	// https://www.metabase.com/docs/latest/api#tag/apipermissions/delete/api/permissions/group/{group-id}
+	permissionGroupByID = "/api/permissions/group/%s"
+
+	// https://www.metabase.com/docs/latest/api#tag/apipermissions/get/api/permissions/membership
+	// https://www.metabase.com/docs/latest/api#tag/apipermissions/post/api/permissions/membership
+	memberships = "/api/permissions/membership"
+	/* Example JSON response version 0.56, keyed by user ID:
+	{
+	    "1": [
+	        {"membership_id": 1, "group_id": 1, "user_id": 1, "is_group_manager": false},
+	        {"membership_id": 2, "group_id": 2, "user_id": 1, "is_group_manager": false}
+	    ]
+	}
+	*/
+
+	// https://www.metabase.com/docs/latest/api#tag/apipermissions/delete/api/permissions/membership/{id}
+	// https://www.metabase.com/docs/latest/api#tag/apipermissions/put/api/permissions/membership/{id}
+	// The PUT only changes is_group_manager, which requires a paid plan.
+	membershipByID = "/api/permissions/membership/%s"
+
+	// https://www.metabase.com/docs/latest/api#tag/apidatabase/get/api/database/{id}/schemas
+	getDatabaseSchemas = "/api/database/%s/schemas"
+
+	// https://www.metabase.com/docs/latest/api#tag/apidatabase/get/api/database/{id}/schema/{schema}
+	getSchemaTables = "/api/database/%s/schema/%s"
+
+	// https://www.metabase.com/docs/latest/api#tag/apitable/get/api/table/{id}
+	getTable = "/api/table/%s"
+
 	// https://www.metabase.com/docs/latest/api#tag/apipermissions/get/api/permissions/graph/db/{db-id}
 	getDBPermissions = "/api/permissions/graph/db/%s"
 	/* Example JSON response version 0.56:
@@ -62,17 +136,199 @@ const (
 	        }
 	    }
 	}
+	When permissions are granular, a value is an object keyed by schema and then by table ID instead of a string:
+	"view-data": {"PUBLIC": {"11": "unrestricted", "12": "blocked"}, "ANALYTICS": "unrestricted"}
+	*/
+
+	// https://www.metabase.com/docs/latest/api#tag/apicollection/get/api/collection/
+	getCollections = "/api/collection"
+
+	// https://www.metabase.com/docs/latest/api#tag/apicollection/get/api/collection/{id}/items
+	getCollectionItems = "/api/collection/%d/items"
+	/* Example JSON response version 0.56:
+	{
+	    "data": [
+	        {"id": 12, "name": "Revenue by month", "model": "card"},
+	        {"id": 3, "name": "Sales overview", "model": "dashboard"},
+	        {"id": 8, "name": "Drafts", "model": "collection"}
+	    ],
+	    "total": 3
+	}
+	*/
+
+	// Items are archived with {"archived": true} and moved with {"collection_id": id}, or {"parent_id": id} for collections.
+	// https://www.metabase.com/docs/latest/api#tag/apicollection/put/api/collection/{id}
+	collectionByID = "/api/collection/%d"
+
+	// https://www.metabase.com/docs/latest/api#tag/apidashboard/put/api/dashboard/{id}
+	dashboardByID = "/api/dashboard/%d"
+
+	// https://www.metabase.com/docs/latest/api#tag/apicard/put/api/card/{id}
+	cardByID = "/api/card/%d"
+
+	// https://www.metabase.com/docs/latest/api#tag/apidashboard/get/api/dashboard/
+	getDashboards = "/api/dashboard"
+
+	// https://www.metabase.com/docs/latest/api#tag/apicard/get/api/card/
+	getCards = "/api/card"
+	/* Example JSON response version 0.56, dashboards have the same fields except type and database_id:
+	[
+	    {
+	        "id": 12,
+	        "name": "Revenue by month",
+	        "description": null,
+	        "type": "question",
+	        "collection_id": 4,
+	        "database_id": 2,
+	        "creator_id": 1,
+	        "archived": false,
+	        "public_uuid": "a7c1b0d9-3a5e-4a2b-9a0f-2f4b2b8d1c11",
+	        "enable_embedding": false,
+	        "created_at": "2025-06-01T10:00:00.000Z",
+	        "updated_at": "2025-06-02T10:00:00.000Z"
+	    }
+	]
+	*/
+
+	// https://www.metabase.com/docs/latest/api#tag/apicollection/get/api/collection/graph
+	getCollectionGraph = "/api/collection/graph"
+	/* Example JSON response version 0.56:
+	{
+	    "revision": 4,
+	    "groups": {
+	        "1": {"root": "read", "2": "none"},
+	        "3": {"root": "write", "2": "write"}
+	    }
+	}
 	*/
+
+	// https://www.metabase.com/docs/latest/api#tag/apicollection/put/api/collection/graph
+	// Like the data permission graph, only the groups and collections present in the body are modified.
+	updateCollectionGraph = "/api/collection/graph"
+
+	// Paid plans only. Read with GET and updated with PUT, where only the groups present in the body are modified.
+	// https://www.metabase.com/docs/latest/api#tag/apieeadvanced-permissionsapplication/get/api/ee/advanced-permissions/application/graph
+	applicationGraph = "/api/ee/advanced-permissions/application/graph"
+	/* Example JSON response version 0.56:
+	{
+	    "revision": 2,
+	    "groups": {
+	        "1": {"setting": "no", "monitoring": "no", "subscription": "yes"},
+	        "2": {"setting": "yes", "monitoring": "yes", "subscription": "yes"}
+	    }
+	}
+	*/
+
+	// Paid plans only. Sandboxes (group table access policies) filter the rows of a table that a group sees.
+	// https://www.metabase.com/docs/latest/api#tag/apimtgtap/get/api/mt/gtap/
+	// https://www.metabase.com/docs/latest/api#tag/apimtgtap/post/api/mt/gtap/
+	sandboxes = "/api/mt/gtap"
+	/* Example JSON response version 0.56, filtered with ?table_id=12:
+	[
+	    {
+	        "id": 1,
+	        "group_id": 3,
+	        "table_id": 12,
+	        "card_id": null,
+	        "attribute_remappings": {
+	            "tenant_id": ["dimension", ["field", 45, null]]
+	        }
+	    }
+	]
+	*/
+
+	// https://www.metabase.com/docs/latest/api#tag/apimtgtap/delete/api/mt/gtap/{id}
+	sandboxByID = "/api/mt/gtap/%s"
+
+	// https://www.metabase.com/docs/latest/api#tag/apipermissions/put/api/permissions/graph
+	// Only the groups and databases present in the body are modified, and the revision must match the
+	// current one or Metabase answers with a 409 Conflict.
+	updatePermissionsGraph = "/api/permissions/graph"
+
+	// https://www.metabase.com/docs/latest/api#tag/apipermissions/get/api/permissions/graph
+	// The revision is global to the data permission graph and increases on every change. The groups hold the
+	// permissions of every database, in the same shape as the graph of a single database.
+	getPermissionsGraph = "/api/permissions/graph"
+
+	// https://www.metabase.com/docs/latest/api#tag/apidatabase/get/api/database/{id}/metadata
+	getDatabaseMetadata = "/api/database/%d/metadata"
+
+	// https://www.metabase.com/docs/latest/api#tag/apidataset/post/api/dataset/
+	runDatasetQuery = "/api/dataset"
+	/* Example JSON response version 0.56, rows are in the order of cols:
+	{
+	    "status": "completed",
+	    "data": {
+	        "cols": [{"name": "id"}, {"name": "topic"}, {"name": "timestamp"}, {"name": "user_id"}, {"name": "entity_type"}, {"name": "entity_id"}],
+	        "rows": [[41, "user-deactivated", "2025-06-01T10:00:00.123Z", 1, "user", 7]]
+	    }
+	}
+	*/
+
+	// Paid plans record the audit log in an internal database that Metabase creates with this fixed ID.
+	// Only query builder (MBQL) queries are allowed on it, so fields are referenced by ID.
+	auditDatabaseID   = 13371337
+	auditLogTableName = "v_audit_log"
 )
 
+// ErrConflict matches the APIError of a write that Metabase rejects with 409 Conflict, e.g. when a permission
+// graph was modified by someone else since it was read.
+var ErrConflict = errors.New("metabase API conflict")
+
+// ErrUnauthorized matches the APIError of a request whose credentials Metabase rejects with 401 Unauthorized, e.g.
+// when a session expired.
+var ErrUnauthorized = errors.New("metabase API unauthorized")
+
+// ErrUnsupportedItem is returned when an item of a collection can be neither archived nor moved by the client,
+// like pulses and snippets.
+var ErrUnsupportedItem = errors.New("unsupported collection item")
+
+// Credentials authenticate the client, either with an API key or with the email and password of a user. The
+// password is only used to log in and get a session.
+type Credentials struct {
+	APIKey   string
+	Username string
+	Password string
+}
+
 type MetabaseV056Client struct {
-	client     *uhttp.BaseHttpClient
-	baseURL    *url.URL
-	apiKey     string
-	isPaidPlan bool
+	client      *uhttp.BaseHttpClient
+	baseURL     *url.URL
+	credentials Credentials
+	isPaidPlan  bool
+	retryPolicy RetryPolicy
+
+	// sessionID authenticates the requests of clients without an API key. It is obtained on first use and
+	// renewed when Metabase rejects it.
+	sessionID string
+	sessionMu sync.Mutex
+
+	// version is the Metabase release, fetched on first use to pick the decoders of the release.
+	version   *Version
+	versionMu sync.Mutex
+
+	// auditLog holds the IDs the audit log is queried with, fetched on first use.
+	auditLog   *auditLogFields
+	auditLogMu sync.Mutex
+}
+
+// auditLogFields are the IDs of the audit log table and of its timestamp and id fields.
+type auditLogFields struct {
+	tableID          int
+	timestampFieldID int
+	idFieldID        int
 }
 
-func NewV056Client(ctx context.Context, rawBaseURL string, apiKey string, isPaidPlan bool) (*MetabaseV056Client, error) {
+func NewV056Client(ctx context.Context, rawBaseURL string, credentials Credentials, isPaidPlan bool, retryPolicy RetryPolicy) (*MetabaseV056Client, error) {
+	if credentials.APIKey == "" && (credentials.Username == "" || credentials.Password == "") {
+		return nil, fmt.Errorf("baton-metabase-v056: either an API key or a username and password are required")
+	}
+
+	retryPolicy, err := retryPolicy.validate()
+	if err != nil {
+		return nil, err
+	}
+
 	client, err := uhttp.NewClient(ctx)
 	if err != nil {
 		return nil, err
@@ -89,24 +345,155 @@ func NewV056Client(ctx context.Context, rawBaseURL string, apiKey string, isPaid
 	}
 
 	return &MetabaseV056Client{
-		client:     httpClient,
-		baseURL:    baseURL,
-		apiKey:     apiKey,
-		isPaidPlan: isPaidPlan,
+		client:      httpClient,
+		baseURL:     baseURL,
+		credentials: credentials,
+		isPaidPlan:  isPaidPlan,
+		retryPolicy: retryPolicy,
 	}, nil
 }
 
 type ReqOpt func(reqURL *url.URL)
 
+func withPageOptions(opts PageOptions) ReqOpt {
+	return func(reqURL *url.URL) {
+		if opts.Limit <= 0 {
+			return
+		}
+		q := reqURL.Query()
+		q.Set("limit", strconv.Itoa(opts.Limit))
+		q.Set("offset", strconv.Itoa(opts.Offset))
+		reqURL.RawQuery = q.Encode()
+	}
+}
+
+func withQueryParam(key string, value string) ReqOpt {
+	return func(reqURL *url.URL) {
+		q := reqURL.Query()
+		q.Set(key, value)
+		reqURL.RawQuery = q.Encode()
+	}
+}
+
 func (c *MetabaseV056Client) doRequest(ctx context.Context, method string, url *url.URL, target interface{}, body interface{}, opts ...ReqOpt) (*http.Header, *v2.RateLimitDescription, error) {
+	return c.doRequestWithOptions(ctx, method, url, target, body, nil, opts...)
+}
+
+// doUncachedGet performs a GET that bypasses the HTTP cache, for values that must be current, like the revision
+// of the permission graph.
+func (c *MetabaseV056Client) doUncachedGet(ctx context.Context, url *url.URL, target interface{}, opts ...ReqOpt) (*http.Header, *v2.RateLimitDescription, error) {
+	return c.doRequestWithOptions(ctx, http.MethodGet, url, target, nil, []uhttp.RequestOption{uhttp.WithNoCache()}, opts...)
+}
+
+func (c *MetabaseV056Client) doRequestWithOptions(
+	ctx context.Context,
+	method string,
+	url *url.URL,
+	target interface{},
+	body interface{},
+	requestOptions []uhttp.RequestOption,
+	opts ...ReqOpt,
+) (*http.Header, *v2.RateLimitDescription, error) {
 	for _, opt := range opts {
 		opt(url)
 	}
 
+	// Only GETs are retried, since they can be sent again without changing anything.
+	if method != http.MethodGet {
+		return c.authenticatedSend(ctx, method, url, target, body, requestOptions)
+	}
+
+	return c.retry(ctx, func() (*http.Header, *v2.RateLimitDescription, error) {
+		return c.authenticatedSend(ctx, method, url, target, body, requestOptions)
+	})
+}
+
+// authenticatedSend performs a request with the API key, or with the session, logging in again when it expired.
+func (c *MetabaseV056Client) authenticatedSend(
+	ctx context.Context,
+	method string,
+	url *url.URL,
+	target interface{},
+	body interface{},
+	requestOptions []uhttp.RequestOption,
+) (*http.Header, *v2.RateLimitDescription, error) {
+	if c.credentials.APIKey != "" {
+		return c.send(ctx, method, url, target, body, append(requestOptions, uhttp.WithHeader(headerAPIKey, c.credentials.APIKey))...)
+	}
+
+	sessionID, rateLimitDesc, err := c.session(ctx, "")
+	if err != nil {
+		return nil, rateLimitDesc, err
+	}
+
+	header, rateLimitDesc, err := c.send(ctx, method, url, target, body, append(requestOptions, uhttp.WithHeader(headerSession, sessionID))...)
+	if !errors.Is(err, ErrUnauthorized) {
+		return header, rateLimitDesc, err
+	}
+
+	// Sessions expire after a while or when they are revoked, so log in again once and retry.
+	sessionID, rateLimitDesc, err = c.session(ctx, sessionID)
+	if err != nil {
+		return nil, rateLimitDesc, err
+	}
+
+	return c.send(ctx, method, url, target, body, append(requestOptions, uhttp.WithHeader(headerSession, sessionID))...)
+}
+
+// session returns the ID of the current session, logging in when there is none or when it is the expired one.
+func (c *MetabaseV056Client) session(ctx context.Context, expired string) (string, *v2.RateLimitDescription, error) {
+	c.sessionMu.Lock()
+	defer c.sessionMu.Unlock()
+
+	if c.sessionID != "" && c.sessionID != expired {
+		return c.sessionID, nil, nil
+	}
+
+	var loginResp LoginResponse
+
+	queryUrl := c.baseURL.JoinPath(userSession)
+
+	_, rateLimitDesc, err := c.send(ctx, http.MethodPost, queryUrl, &loginResp, &LoginRequest{
+		Username: c.credentials.Username,
+		Password: c.credentials.Password,
+	})
+	if err != nil {
+		return "", rateLimitDesc, fmt.Errorf("failed to log in to Metabase: %w", err)
+	}
+
+	if loginResp.ID == "" {
+		return "", rateLimitDesc, fmt.Errorf("failed to log in to Metabase: no session was returned")
+	}
+
+	c.sessionID = loginResp.ID
+	return c.sessionID, rateLimitDesc, nil
+}
+
+// Logout ends the session of the client, if it has one. Clients authenticated with an API key have none.
+func (c *MetabaseV056Client) Logout(ctx context.Context) (*v2.RateLimitDescription, error) {
+	c.sessionMu.Lock()
+	defer c.sessionMu.Unlock()
+
+	if c.sessionID == "" {
+		return nil, nil
+	}
+
+	queryUrl := c.baseURL.JoinPath(userSession)
+
+	_, rateLimitDesc, err := c.send(ctx, http.MethodDelete, queryUrl, nil, nil, uhttp.WithHeader(headerSession, c.sessionID))
+	c.sessionID = ""
+	if err != nil {
+		return rateLimitDesc, fmt.Errorf("failed to log out of Metabase: %w", err)
+	}
+
+	return rateLimitDesc, nil
+}
+
+// send performs a single request, with the given options like the authentication header, if any.
+func (c *MetabaseV056Client) send(ctx context.Context, method string, url *url.URL, target interface{}, body interface{}, options ...uhttp.RequestOption) (*http.Header, *v2.RateLimitDescription, error) {
 	var requestOptions []uhttp.RequestOption
-	requestOptions = append(requestOptions,
-		uhttp.WithAcceptJSONHeader(),
-		uhttp.WithHeader(headerAPIKey, c.apiKey))
+	requestOptions = append(requestOptions, uhttp.WithAcceptJSONHeader())
+	requestOptions = append(requestOptions, options...)
 	if body != nil {
 		requestOptions = append(requestOptions, uhttp.WithContentTypeJSONHeader(), uhttp.WithJSONBody(body))
 	}
@@ -118,8 +505,10 @@ func (c *MetabaseV056Client) doRequest(ctx context.Context, method string, url *
 
 	var rateLimitData v2.RateLimitDescription
 	response, err := c.client.Do(request, uhttp.WithRatelimitData(&rateLimitData))
-	if err != nil {
-		return nil, nil, fmt.Errorf("request failed: %w", err)
+	if response == nil {
+		// Requests fail without a response when Metabase cannot be reached, e.g. while it restarts, unless the
+		// context is done.
+		return nil, nil, &APIError{Message: err.Error(), Retryable: ctx.Err() == nil, Err: err}
 	}
 
 	defer func() {
@@ -130,13 +519,17 @@ func (c *MetabaseV056Client) doRequest(ctx context.Context, method string, url *
 		}
 	}()
 
+	if response.StatusCode < 300 && err != nil {
+		return nil, &rateLimitData, fmt.Errorf("request failed: %w", err)
+	}
+
 	// The Metabase API does not always return a JSON-formatted error body,
 	// so we first read the raw response and try to parse it as JSON.
 	// If parsing fails or the response is empty, we fall back to using the HTTP status text.
 	if response.StatusCode >= 300 {
-		bodyBytes, err := io.ReadAll(response.Body)
-		if err != nil {
-			return nil, &rateLimitData, fmt.Errorf("failed to read response body: %w", err)
+		bodyBytes, readErr := io.ReadAll(response.Body)
+		if readErr != nil {
+			return nil, &rateLimitData, fmt.Errorf("failed to read response body: %w", readErr)
 		}
 		bodyStr := strings.TrimSpace(string(bodyBytes))
 
@@ -149,8 +542,21 @@ func (c *MetabaseV056Client) doRequest(ctx context.Context, method string, url *
 			bodyStr = http.StatusText(response.StatusCode)
 		}
 
-		return nil, &rateLimitData, fmt.Errorf("metabase API error: status %d %s: %s",
-			response.StatusCode, response.Status, bodyStr)
+		statusErr := &APIError{
+			StatusCode: response.StatusCode,
+			Message:    bodyStr,
+			Retryable:  isRetryableStatus(response.StatusCode),
+			Err:        err,
+		}
+		if response.StatusCode == http.StatusTooManyRequests || response.StatusCode == http.StatusServiceUnavailable {
+			statusErr.RetryAfter = parseRetryAfter(response.Header.Get("Retry-After"))
+			if statusErr.RetryAfter > 0 {
+				withRetryAfter(&rateLimitData, statusErr.RetryAfter)
+			}
+		}
+
+		// uhttp returns the response along with an error for error statuses, which is kept in the APIError.
+		return nil, &rateLimitData, statusErr
 	}
 
 	if target != nil {
@@ -162,30 +568,711 @@ func (c *MetabaseV056Client) doRequest(ctx context.Context, method string, url *
 	return &response.Header, &rateLimitData, nil
 }
 
-func (c *MetabaseV056Client) ListDatabases(ctx context.Context) ([]*Database, *v2.RateLimitDescription, error) {
+// ListUsers returns a page of users, active or not, along with the total number of users. The user activity feed
+// compares the users with the ones it listed before, so they are never served from the HTTP cache.
+func (c *MetabaseV056Client) ListUsers(ctx context.Context, opts PageOptions) ([]*User, int, *v2.RateLimitDescription, error) {
+	var usersResponse UsersAPIResponse
+
+	queryUrl := c.baseURL.JoinPath(getUsers)
+
+	_, rateLimitDesc, err := c.doUncachedGet(ctx, queryUrl, &usersResponse,
+		withQueryParam("status", "all"),
+		withPageOptions(opts),
+	)
+	if err != nil {
+		return nil, 0, rateLimitDesc, fmt.Errorf("failed to fetch users: %w", err)
+	}
+
+	return usersResponse.Data, usersResponse.Total, rateLimitDesc, nil
+}
+
+// GetUser returns the user. It is only read to decide on a change of the user, like granting admin rights or
+// deactivating it, so it is never served from the HTTP cache.
+func (c *MetabaseV056Client) GetUser(ctx context.Context, userID string) (*User, *v2.RateLimitDescription, error) {
+	var user User
+
+	queryUrl := c.baseURL.JoinPath(fmt.Sprintf(userByID, url.PathEscape(userID)))
+
+	_, rateLimitDesc, err := c.doUncachedGet(ctx, queryUrl, &user)
+	if err != nil {
+		return nil, rateLimitDesc, fmt.Errorf("failed to fetch user %s: %w", userID, err)
+	}
+
+	return &user, rateLimitDesc, nil
+}
+
+// UpdateUser changes the fields of the user that are set in update.
+func (c *MetabaseV056Client) UpdateUser(ctx context.Context, userID string, update *UserUpdate) (*User, *v2.RateLimitDescription, error) {
+	var user User
+
+	queryUrl := c.baseURL.JoinPath(fmt.Sprintf(userByID, url.PathEscape(userID)))
+
+	_, rateLimitDesc, err := c.doRequest(ctx, http.MethodPut, queryUrl, &user, update)
+	if err != nil {
+		return nil, rateLimitDesc, fmt.Errorf("failed to update user %s: %w", userID, err)
+	}
+
+	return &user, rateLimitDesc, nil
+}
+
+func (c *MetabaseV056Client) CreateUser(ctx context.Context, request *CreateUserRequest) (*User, *v2.RateLimitDescription, error) {
+	var user User
+
+	queryUrl := c.baseURL.JoinPath(createUser)
+
+	_, rateLimitDesc, err := c.doRequest(ctx, http.MethodPost, queryUrl, &user, request)
+	if err != nil {
+		return nil, rateLimitDesc, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	return &user, rateLimitDesc, nil
+}
+
+// UpdateUserActiveStatus reactivates or deactivates the user. Metabase never deletes users, deactivating them instead.
+func (c *MetabaseV056Client) UpdateUserActiveStatus(ctx context.Context, userID string, active bool) (*User, *v2.RateLimitDescription, error) {
+	method := http.MethodDelete
+	queryUrl := c.baseURL.JoinPath(fmt.Sprintf(userByID, url.PathEscape(userID)))
+	if active {
+		method = http.MethodPut
+		queryUrl = c.baseURL.JoinPath(fmt.Sprintf(reactivateUser, url.PathEscape(userID)))
+	}
+
+	var user User
+
+	_, rateLimitDesc, err := c.doRequest(ctx, method, queryUrl, &user, nil)
+	if err != nil {
+		return nil, rateLimitDesc, fmt.Errorf("failed to update active status of user %s: %w", userID, err)
+	}
+
+	return &user, rateLimitDesc, nil
+}
+
+func (c *MetabaseV056Client) ListGroups(ctx context.Context) ([]*Group, *v2.RateLimitDescription, error) {
+	var groups []*Group
+
+	queryUrl := c.baseURL.JoinPath(permissionGroups)
+
+	_, rateLimitDesc, err := c.doRequest(ctx, http.MethodGet, queryUrl, &groups, nil)
+	if err != nil {
+		return nil, rateLimitDesc, fmt.Errorf("failed to fetch groups: %w", err)
+	}
+
+	return groups, rateLimitDesc, nil
+}
+
+func (c *MetabaseV056Client) CreateGroup(ctx context.Context, name string) (*Group, *v2.RateLimitDescription, error) {
+	var group Group
+
+	queryUrl := c.baseURL.JoinPath(permissionGroups)
+
+	_, rateLimitDesc, err := c.doRequest(ctx, http.MethodPost, queryUrl, &group, &GroupRequest{Name: name})
+	if err != nil {
+		return nil, rateLimitDesc, fmt.Errorf("failed to create group %s: %w", name, err)
+	}
+
+	return &group, rateLimitDesc, nil
+}
+
+func (c *MetabaseV056Client) RenameGroup(ctx context.Context, groupID string, name string) (*Group, *v2.RateLimitDescription, error) {
+	var group Group
+
+	queryUrl := c.baseURL.JoinPath(fmt.Sprintf(permissionGroupByID, url.PathEscape(groupID)))
+
+	_, rateLimitDesc, err := c.doRequest(ctx, http.MethodPut, queryUrl, &group, &GroupRequest{Name: name})
+	if err != nil {
+		return nil, rateLimitDesc, fmt.Errorf("failed to rename group %s: %w", groupID, err)
+	}
+
+	return &group, rateLimitDesc, nil
+}
+
+// DeleteGroup deletes the group along with its memberships and permissions.
+func (c *MetabaseV056Client) DeleteGroup(ctx context.Context, groupID string) (*v2.RateLimitDescription, error) {
+	queryUrl := c.baseURL.JoinPath(fmt.Sprintf(permissionGroupByID, url.PathEscape(groupID)))
+
+	_, rateLimitDesc, err := c.doRequest(ctx, http.MethodDelete, queryUrl, nil, nil)
+	if err != nil {
+		return rateLimitDesc, fmt.Errorf("failed to delete group %s: %w", groupID, err)
+	}
+
+	return rateLimitDesc, nil
+}
+
+// ListMemberships returns the group memberships of every user, keyed by user ID.
+func (c *MetabaseV056Client) ListMemberships(ctx context.Context) (map[string][]*Membership, *v2.RateLimitDescription, error) {
+	var membershipsResp map[string][]*Membership
+
+	queryUrl := c.baseURL.JoinPath(memberships)
+
+	// Syncs read the memberships once, and provisioning reads them right before changing them, so they must not
+	// be served from the HTTP cache.
+	_, rateLimitDesc, err := c.doUncachedGet(ctx, queryUrl, &membershipsResp)
+	if err != nil {
+		return nil, rateLimitDesc, fmt.Errorf("failed to fetch memberships: %w", err)
+	}
+
+	return membershipsResp, rateLimitDesc, nil
+}
+
+func (c *MetabaseV056Client) AddUserToGroup(ctx context.Context, membership *Membership) (*v2.RateLimitDescription, error) {
+	queryUrl := c.baseURL.JoinPath(memberships)
+
+	_, rateLimitDesc, err := c.doRequest(ctx, http.MethodPost, queryUrl, nil, membership)
+	if err != nil {
+		return rateLimitDesc, fmt.Errorf("failed to add user %d to group %d: %w", membership.UserID, membership.GroupID, err)
+	}
+
+	return rateLimitDesc, nil
+}
+
+func (c *MetabaseV056Client) RemoveUserFromGroup(ctx context.Context, membershipID string) (*v2.RateLimitDescription, error) {
+	queryUrl := c.baseURL.JoinPath(fmt.Sprintf(membershipByID, url.PathEscape(membershipID)))
+
+	_, rateLimitDesc, err := c.doRequest(ctx, http.MethodDelete, queryUrl, nil, nil)
+	if err != nil {
+		return rateLimitDesc, fmt.Errorf("failed to remove membership %s: %w", membershipID, err)
+	}
+
+	return rateLimitDesc, nil
+}
+
+// SetGroupManager promotes the member of a group to group manager, or demotes the manager to a plain member.
+func (c *MetabaseV056Client) SetGroupManager(ctx context.Context, membershipID string, isGroupManager bool) (*v2.RateLimitDescription, error) {
+	queryUrl := c.baseURL.JoinPath(fmt.Sprintf(membershipByID, url.PathEscape(membershipID)))
+
+	body := map[string]bool{"is_group_manager": isGroupManager}
+
+	_, rateLimitDesc, err := c.doRequest(ctx, http.MethodPut, queryUrl, nil, body)
+	if err != nil {
+		return rateLimitDesc, fmt.Errorf("failed to update membership %s: %w", membershipID, err)
+	}
+
+	return rateLimitDesc, nil
+}
+
+func (c *MetabaseV056Client) ListAPIKeys(ctx context.Context) ([]*APIKey, *v2.RateLimitDescription, error) {
+	var apiKeys []*APIKey
+
+	queryUrl := c.baseURL.JoinPath(getAPIKeys)
+
+	_, rateLimitDesc, err := c.doRequest(ctx, http.MethodGet, queryUrl, &apiKeys, nil)
+	if err != nil {
+		return nil, rateLimitDesc, fmt.Errorf("failed to fetch API keys: %w", err)
+	}
+
+	return apiKeys, rateLimitDesc, nil
+}
+
+// RegenerateAPIKey replaces the key of the API key and returns the new unmasked key, which Metabase never shows again.
+func (c *MetabaseV056Client) RegenerateAPIKey(ctx context.Context, apiKeyID string) (*RegeneratedAPIKey, *v2.RateLimitDescription, error) {
+	var regenerated RegeneratedAPIKey
+
+	queryUrl := c.baseURL.JoinPath(fmt.Sprintf(regenerateAPIKey, url.PathEscape(apiKeyID)))
+
+	_, rateLimitDesc, err := c.doRequest(ctx, http.MethodPut, queryUrl, &regenerated, nil)
+	if err != nil {
+		return nil, rateLimitDesc, fmt.Errorf("failed to regenerate API key %s: %w", apiKeyID, err)
+	}
+
+	return &regenerated, rateLimitDesc, nil
+}
+
+func (c *MetabaseV056Client) DeleteAPIKey(ctx context.Context, apiKeyID string) (*v2.RateLimitDescription, error) {
+	queryUrl := c.baseURL.JoinPath(fmt.Sprintf(deleteAPIKey, url.PathEscape(apiKeyID)))
+
+	_, rateLimitDesc, err := c.doRequest(ctx, http.MethodDelete, queryUrl, nil, nil)
+	if err != nil {
+		return rateLimitDesc, fmt.Errorf("failed to delete API key %s: %w", apiKeyID, err)
+	}
+
+	return rateLimitDesc, nil
+}
+
+// ListDatabases returns a page of databases along with the total number of databases.
+func (c *MetabaseV056Client) ListDatabases(ctx context.Context, opts DatabaseListOptions) ([]*Database, int, *v2.RateLimitDescription, error) {
 	var dbResponse DatabaseAPIResponse
 
 	queryUrl := c.baseURL.JoinPath(getDatabases)
 
-	_, rateLimitDesc, err := c.doRequest(ctx, http.MethodGet, queryUrl, &dbResponse, nil)
+	reqOpts := []ReqOpt{withPageOptions(opts.PageOptions)}
+	if opts.IncludeTables {
+		reqOpts = append(reqOpts, withQueryParam("include", "tables"))
+	}
+	if opts.Saved {
+		reqOpts = append(reqOpts, withQueryParam("saved", "true"))
+	}
+
+	_, rateLimitDesc, err := c.doRequest(ctx, http.MethodGet, queryUrl, &dbResponse, nil, reqOpts...)
+	if err != nil {
+		return nil, 0, rateLimitDesc, fmt.Errorf("failed to fetch databases: %w", err)
+	}
+
+	return dbResponse.Data, dbResponse.Total, rateLimitDesc, nil
+}
+
+func (c *MetabaseV056Client) ListSchemas(ctx context.Context, dbID string) ([]string, *v2.RateLimitDescription, error) {
+	var schemas []string
+
+	queryUrl := c.baseURL.JoinPath(fmt.Sprintf(getDatabaseSchemas, url.PathEscape(dbID)))
+
+	_, rateLimitDesc, err := c.doRequest(ctx, http.MethodGet, queryUrl, &schemas, nil)
+	if err != nil {
+		return nil, rateLimitDesc, fmt.Errorf("failed to fetch schemas of database %s: %w", dbID, err)
+	}
+
+	return schemas, rateLimitDesc, nil
+}
+
+func (c *MetabaseV056Client) ListTables(ctx context.Context, dbID string, schema string) ([]*Table, *v2.RateLimitDescription, error) {
+	var tables []*Table
+
+	queryUrl := c.baseURL.JoinPath(fmt.Sprintf(getSchemaTables, url.PathEscape(dbID), url.PathEscape(schema)))
+
+	_, rateLimitDesc, err := c.doRequest(ctx, http.MethodGet, queryUrl, &tables, nil)
 	if err != nil {
-		return nil, rateLimitDesc, fmt.Errorf("failed to fetch databases: %w", err)
+		return nil, rateLimitDesc, fmt.Errorf("failed to fetch tables of schema %s in database %s: %w", schema, dbID, err)
 	}
 
-	return dbResponse.Data, rateLimitDesc, nil
+	return tables, rateLimitDesc, nil
 }
 
-func (c *MetabaseV056Client) GetDBPermissions(ctx context.Context, dbID string) (map[string]map[string]*GroupPermission, *v2.RateLimitDescription, error) {
-	var dbPermissions DBPermissionGraph
+// GetTable returns a table with the database and schema it belongs to.
+func (c *MetabaseV056Client) GetTable(ctx context.Context, tableID string) (*Table, *v2.RateLimitDescription, error) {
+	var table Table
+
+	queryUrl := c.baseURL.JoinPath(fmt.Sprintf(getTable, url.PathEscape(tableID)))
+
+	_, rateLimitDesc, err := c.doRequest(ctx, http.MethodGet, queryUrl, &table, nil)
+	if err != nil {
+		return nil, rateLimitDesc, fmt.Errorf("failed to fetch table %s: %w", tableID, err)
+	}
+
+	return &table, rateLimitDesc, nil
+}
 
+// GetDBPermissions returns the permission graph of the database, decoded into the view-data and create-queries
+// dimensions whatever the shape of the graph of the Metabase release.
+func (c *MetabaseV056Client) GetDBPermissions(ctx context.Context, dbID string) (*DBPermissionGraph, *v2.RateLimitDescription, error) {
 	queryUrl := c.baseURL.JoinPath(fmt.Sprintf(getDBPermissions, url.PathEscape(dbID)))
 
-	_, rateLimitDesc, err := c.doRequest(ctx, http.MethodGet, queryUrl, &dbPermissions, nil)
+	graph, rateLimitDesc, err := c.getPermissionGraph(ctx, queryUrl)
 	if err != nil {
 		return nil, rateLimitDesc, fmt.Errorf("failed to fetch database permissions %s: %w", dbID, err)
 	}
 
-	return dbPermissions.Groups, rateLimitDesc, nil
+	return graph, rateLimitDesc, nil
+}
+
+// GetPermissionGraph returns the permission graph of every database in a single request, decoded like the graph
+// of a single database.
+func (c *MetabaseV056Client) GetPermissionGraph(ctx context.Context) (*DBPermissionGraph, *v2.RateLimitDescription, error) {
+	queryUrl := c.baseURL.JoinPath(getPermissionsGraph)
+
+	graph, rateLimitDesc, err := c.getPermissionGraph(ctx, queryUrl)
+	if err != nil {
+		return nil, rateLimitDesc, fmt.Errorf("failed to fetch permission graph: %w", err)
+	}
+
+	return graph, rateLimitDesc, nil
+}
+
+func (c *MetabaseV056Client) getPermissionGraph(ctx context.Context, queryUrl *url.URL) (*DBPermissionGraph, *v2.RateLimitDescription, error) {
+	version, rateLimitDesc, err := c.serverVersion(ctx)
+	if err != nil {
+		return nil, rateLimitDesc, err
+	}
+
+	var rawGraph struct {
+		Revision int                                   `json:"revision"`
+		Groups   map[string]map[string]json.RawMessage `json:"groups"`
+	}
+
+	// Graphs are read right before they are updated, so they must not be served from the HTTP cache.
+	_, rateLimitDesc, err = c.doUncachedGet(ctx, queryUrl, &rawGraph)
+	if err != nil {
+		return nil, rateLimitDesc, err
+	}
+
+	decode := groupPermissionDecoderFor(version)
+
+	dbPermissions := &DBPermissionGraph{
+		Revision: rawGraph.Revision,
+		Groups:   make(map[string]map[string]*GroupPermission, len(rawGraph.Groups)),
+	}
+	for groupID, databases := range rawGraph.Groups {
+		dbPermissions.Groups[groupID] = make(map[string]*GroupPermission, len(databases))
+		for id, data := range databases {
+			permission, err := decode(data)
+			if err != nil {
+				return nil, rateLimitDesc, fmt.Errorf("failed to decode permissions of group %s on database %s: %w", groupID, id, err)
+			}
+			dbPermissions.Groups[groupID][id] = permission
+		}
+	}
+
+	return dbPermissions, rateLimitDesc, nil
+}
+
+// UpdatePermissionGraph writes the view-data and create-queries dimensions, which only exist since Metabase 0.50.
+func (c *MetabaseV056Client) UpdatePermissionGraph(ctx context.Context, graph *DBPermissionGraph) (*v2.RateLimitDescription, error) {
+	version, rateLimitDesc, err := c.serverVersion(ctx)
+	if err != nil {
+		return rateLimitDesc, err
+	}
+
+	if version.Minor < viewDataGraphMinor {
+		return rateLimitDesc, fmt.Errorf("baton-metabase-v056: updating data permissions requires Metabase v0.%d or later, got %s", viewDataGraphMinor, version)
+	}
+
+	var updateResp DBPermissionGraph
+
+	queryUrl := c.baseURL.JoinPath(updatePermissionsGraph)
+
+	_, rateLimitDesc, err = c.doRequest(ctx, http.MethodPut, queryUrl, &updateResp, graph, withQueryParam("skip-graph", "true"))
+	if err != nil {
+		return rateLimitDesc, fmt.Errorf("failed to update permission graph at revision %d: %w", graph.Revision, err)
+	}
+
+	return rateLimitDesc, nil
+}
+
+// ListSandboxes returns the sandboxes of a table, one per sandboxed group, or the sandboxes of every table when
+// tableID is empty. Provisioning reads them right before creating or deleting one, so they are never served from
+// the HTTP cache.
+func (c *MetabaseV056Client) ListSandboxes(ctx context.Context, tableID string) ([]*Sandbox, *v2.RateLimitDescription, error) {
+	var sandboxList []*Sandbox
+
+	queryUrl := c.baseURL.JoinPath(sandboxes)
+
+	if tableID == "" {
+		_, rateLimitDesc, err := c.doUncachedGet(ctx, queryUrl, &sandboxList)
+		if err != nil {
+			return nil, rateLimitDesc, fmt.Errorf("failed to fetch sandboxes: %w", err)
+		}
+		return sandboxList, rateLimitDesc, nil
+	}
+
+	_, rateLimitDesc, err := c.doUncachedGet(ctx, queryUrl, &sandboxList, withQueryParam("table_id", tableID))
+	if err != nil {
+		return nil, rateLimitDesc, fmt.Errorf("failed to fetch sandboxes of table %s: %w", tableID, err)
+	}
+
+	return sandboxList, rateLimitDesc, nil
+}
+
+func (c *MetabaseV056Client) CreateSandbox(ctx context.Context, sandbox *Sandbox) (*Sandbox, *v2.RateLimitDescription, error) {
+	var created Sandbox
+
+	queryUrl := c.baseURL.JoinPath(sandboxes)
+
+	_, rateLimitDesc, err := c.doRequest(ctx, http.MethodPost, queryUrl, &created, sandbox)
+	if err != nil {
+		return nil, rateLimitDesc, fmt.Errorf("failed to create sandbox of table %d for group %d: %w", sandbox.TableID, sandbox.GroupID, err)
+	}
+
+	return &created, rateLimitDesc, nil
+}
+
+func (c *MetabaseV056Client) DeleteSandbox(ctx context.Context, sandboxID string) (*v2.RateLimitDescription, error) {
+	queryUrl := c.baseURL.JoinPath(fmt.Sprintf(sandboxByID, url.PathEscape(sandboxID)))
+
+	_, rateLimitDesc, err := c.doRequest(ctx, http.MethodDelete, queryUrl, nil, nil)
+	if err != nil {
+		return rateLimitDesc, fmt.Errorf("failed to delete sandbox %s: %w", sandboxID, err)
+	}
+
+	return rateLimitDesc, nil
+}
+
+// ListCollections returns every non-archived collection, including the root collection and the personal
+// collections of every user, with their subcollections, which administrators can all read.
+func (c *MetabaseV056Client) ListCollections(ctx context.Context) ([]*Collection, *v2.RateLimitDescription, error) {
+	var collections []*Collection
+
+	queryUrl := c.baseURL.JoinPath(getCollections)
+
+	_, rateLimitDesc, err := c.doRequest(ctx, http.MethodGet, queryUrl, &collections, nil)
+	if err != nil {
+		return nil, rateLimitDesc, fmt.Errorf("failed to fetch collections: %w", err)
+	}
+
+	return collections, rateLimitDesc, nil
+}
+
+// ListDashboards returns the dashboards of every collection, or only the archived ones when archived is true.
+func (c *MetabaseV056Client) ListDashboards(ctx context.Context, archived bool) ([]*Dashboard, *v2.RateLimitDescription, error) {
+	var dashboards []*Dashboard
+
+	queryUrl := c.baseURL.JoinPath(getDashboards)
+
+	_, rateLimitDesc, err := c.doRequest(ctx, http.MethodGet, queryUrl, &dashboards, nil, withQueryParam("f", contentFilter(archived)))
+	if err != nil {
+		return nil, rateLimitDesc, fmt.Errorf("failed to fetch dashboards: %w", err)
+	}
+
+	return dashboards, rateLimitDesc, nil
+}
+
+// ListCards returns the cards (questions, models and metrics) of every collection, or only the archived ones
+// when archived is true.
+func (c *MetabaseV056Client) ListCards(ctx context.Context, archived bool) ([]*Card, *v2.RateLimitDescription, error) {
+	var cards []*Card
+
+	queryUrl := c.baseURL.JoinPath(getCards)
+
+	_, rateLimitDesc, err := c.doRequest(ctx, http.MethodGet, queryUrl, &cards, nil, withQueryParam("f", contentFilter(archived)))
+	if err != nil {
+		return nil, rateLimitDesc, fmt.Errorf("failed to fetch cards: %w", err)
+	}
+
+	return cards, rateLimitDesc, nil
+}
+
+// ListCollectionItems returns the non-archived items directly in a collection.
+func (c *MetabaseV056Client) ListCollectionItems(ctx context.Context, collectionID int) ([]*CollectionItem, *v2.RateLimitDescription, error) {
+	var itemsResponse CollectionItemsAPIResponse
+
+	queryUrl := c.baseURL.JoinPath(fmt.Sprintf(getCollectionItems, collectionID))
+
+	_, rateLimitDesc, err := c.doRequest(ctx, http.MethodGet, queryUrl, &itemsResponse, nil)
+	if err != nil {
+		return nil, rateLimitDesc, fmt.Errorf("failed to fetch items of collection %d: %w", collectionID, err)
+	}
+
+	return itemsResponse.Data, rateLimitDesc, nil
+}
+
+// UpdateCollectionItem archives or moves an item of a collection. Archiving or moving a collection also archives
+// or moves its content.
+func (c *MetabaseV056Client) UpdateCollectionItem(ctx context.Context, item *CollectionItem, update *CollectionItemUpdate) (*v2.RateLimitDescription, error) {
+	// Collections name their parent parent_id, dashboards and cards name it collection_id.
+	endpoint, parentField := "", "collection_id"
+	switch item.Model {
+	case "card", "dataset", "metric":
+		endpoint = cardByID
+	case "dashboard":
+		endpoint = dashboardByID
+	case "collection":
+		endpoint, parentField = collectionByID, "parent_id"
+	default:
+		return nil, fmt.Errorf("%w: %s %d", ErrUnsupportedItem, item.Model, item.ID)
+	}
+
+	body := make(map[string]interface{})
+	if update.Archived {
+		body["archived"] = true
+	}
+	switch update.CollectionID {
+	case "":
+	case RootCollectionID:
+		// The root collection has no ID: items are moved into it by clearing their parent.
+		body[parentField] = nil
+	default:
+		body[parentField] = json.Number(update.CollectionID)
+	}
+
+	queryUrl := c.baseURL.JoinPath(fmt.Sprintf(endpoint, item.ID))
+
+	_, rateLimitDesc, err := c.doRequest(ctx, http.MethodPut, queryUrl, nil, body)
+	if err != nil {
+		return rateLimitDesc, fmt.Errorf("failed to update %s %d: %w", item.Model, item.ID, err)
+	}
+
+	return rateLimitDesc, nil
+}
+
+// contentFilter returns the value of the f query parameter of the dashboard and card listings.
+func contentFilter(archived bool) string {
+	if archived {
+		return "archived"
+	}
+	return "all"
+}
+
+// GetCollectionPermissions returns the collection permission graph. It is read right before it is updated with its
+// revision, so it is never served from the HTTP cache.
+func (c *MetabaseV056Client) GetCollectionPermissions(ctx context.Context) (*CollectionPermissionGraph, *v2.RateLimitDescription, error) {
+	var graph CollectionPermissionGraph
+
+	queryUrl := c.baseURL.JoinPath(getCollectionGraph)
+
+	_, rateLimitDesc, err := c.doUncachedGet(ctx, queryUrl, &graph)
+	if err != nil {
+		return nil, rateLimitDesc, fmt.Errorf("failed to fetch collection permissions: %w", err)
+	}
+
+	return &graph, rateLimitDesc, nil
+}
+
+func (c *MetabaseV056Client) UpdateCollectionPermissions(ctx context.Context, graph *CollectionPermissionGraph) (*v2.RateLimitDescription, error) {
+	var updateResp CollectionPermissionGraph
+
+	queryUrl := c.baseURL.JoinPath(updateCollectionGraph)
+
+	body := &collectionGraphUpdate{
+		CollectionPermissionGraph: graph,
+		SkipGraph:                 true,
+	}
+
+	_, rateLimitDesc, err := c.doRequest(ctx, http.MethodPut, queryUrl, &updateResp, body)
+	if err != nil {
+		return rateLimitDesc, fmt.Errorf("failed to update collection permissions at revision %d: %w", graph.Revision, err)
+	}
+
+	return rateLimitDesc, nil
+}
+
+// GetApplicationPermissions returns the application permission graph. Like the collection graph, it is read right
+// before it is updated, so it is never served from the HTTP cache.
+func (c *MetabaseV056Client) GetApplicationPermissions(ctx context.Context) (*ApplicationPermissionGraph, *v2.RateLimitDescription, error) {
+	var graph ApplicationPermissionGraph
+
+	queryUrl := c.baseURL.JoinPath(applicationGraph)
+
+	_, rateLimitDesc, err := c.doUncachedGet(ctx, queryUrl, &graph)
+	if err != nil {
+		return nil, rateLimitDesc, fmt.Errorf("failed to fetch application permissions: %w", err)
+	}
+
+	return &graph, rateLimitDesc, nil
+}
+
+func (c *MetabaseV056Client) UpdateApplicationPermissions(ctx context.Context, graph *ApplicationPermissionGraph) (*v2.RateLimitDescription, error) {
+	queryUrl := c.baseURL.JoinPath(applicationGraph)
+
+	_, rateLimitDesc, err := c.doRequest(ctx, http.MethodPut, queryUrl, nil, graph)
+	if err != nil {
+		return rateLimitDesc, fmt.Errorf("failed to update application permissions at revision %d: %w", graph.Revision, err)
+	}
+
+	return rateLimitDesc, nil
+}
+
+// ListAuditLogEntries returns up to limit entries of the audit log that follow the entry with ID afterID recorded
+// at afterTimestamp, oldest first: the entries recorded later, and the ones recorded at the same time with a greater
+// ID. With an afterID of zero, every entry recorded at afterTimestamp is included. The audit log only exists on paid
+// plans.
+func (c *MetabaseV056Client) ListAuditLogEntries(ctx context.Context, afterTimestamp time.Time, afterID int, limit int) ([]*AuditLogEntry, *v2.RateLimitDescription, error) {
+	table, rateLimitDesc, err := c.auditLogTable(ctx)
+	if err != nil {
+		return nil, rateLimitDesc, err
+	}
+
+	query := &DatasetQuery{
+		Database: auditDatabaseID,
+		Type:     "query",
+		Query: map[string]interface{}{
+			"source-table": table.tableID,
+			"filter":       auditLogFilter(table.timestampFieldID, table.idFieldID, afterTimestamp, afterID),
+			"order-by": []interface{}{
+				[]interface{}{"asc", fieldRef(table.timestampFieldID)},
+				[]interface{}{"asc", fieldRef(table.idFieldID)},
+			},
+			"limit": limit,
+		},
+	}
+
+	var dataset DatasetResponse
+
+	queryUrl := c.baseURL.JoinPath(runDatasetQuery)
+
+	_, rateLimitDesc, err = c.doRequest(ctx, http.MethodPost, queryUrl, &dataset, query)
+	if err != nil {
+		return nil, rateLimitDesc, fmt.Errorf("failed to query audit log: %w", err)
+	}
+
+	// Failed queries are answered with 202 Accepted and the error in the body.
+	if dataset.Status == "failed" {
+		return nil, rateLimitDesc, fmt.Errorf("failed to query audit log: %s", dataset.Error)
+	}
+
+	entries, err := dataset.AuditLogEntries()
+	if err != nil {
+		return nil, rateLimitDesc, fmt.Errorf("failed to parse audit log: %w", err)
+	}
+
+	return entries, rateLimitDesc, nil
+}
+
+// auditLogTable returns the IDs of the audit log table and of the fields its queries filter and sort on, fetched
+// from the metadata of the audit database once and then cached.
+func (c *MetabaseV056Client) auditLogTable(ctx context.Context) (auditLogFields, *v2.RateLimitDescription, error) {
+	c.auditLogMu.Lock()
+	defer c.auditLogMu.Unlock()
+
+	if c.auditLog != nil {
+		return *c.auditLog, nil, nil
+	}
+
+	var metadata DatabaseMetadata
+
+	queryUrl := c.baseURL.JoinPath(fmt.Sprintf(getDatabaseMetadata, auditDatabaseID))
+
+	_, rateLimitDesc, err := c.doRequest(ctx, http.MethodGet, queryUrl, &metadata, nil)
+	if err != nil {
+		return auditLogFields{}, rateLimitDesc, fmt.Errorf("failed to fetch audit database metadata: %w", err)
+	}
+
+	table := metadata.Table(auditLogTableName)
+	if table == nil {
+		return auditLogFields{}, rateLimitDesc, fmt.Errorf("baton-metabase-v056: audit log table %s not found", auditLogTableName)
+	}
+
+	timestampField := table.Field("timestamp")
+	idField := table.Field("id")
+	if timestampField == nil || idField == nil {
+		return auditLogFields{}, rateLimitDesc, fmt.Errorf("baton-metabase-v056: audit log table %s has no id or timestamp field", auditLogTableName)
+	}
+
+	c.auditLog = &auditLogFields{
+		tableID:          table.ID,
+		timestampFieldID: timestampField.ID,
+		idFieldID:        idField.ID,
+	}
+	return *c.auditLog, rateLimitDesc, nil
+}
+
+// auditLogFilter returns the MBQL filter of the entries that follow the entry with ID afterID at afterTimestamp.
+// Entries are ordered by timestamp and then ID, so many entries recorded at the same time, like those of a bulk
+// import, are read page by page rather than from the start of their timestamp each time.
+func auditLogFilter(timestampFieldID int, idFieldID int, afterTimestamp time.Time, afterID int) []interface{} {
+	timestamp := afterTimestamp.UTC().Format(time.RFC3339Nano)
+
+	return []interface{}{"or",
+		[]interface{}{">", fieldRef(timestampFieldID), timestamp},
+		[]interface{}{"and",
+			[]interface{}{"=", fieldRef(timestampFieldID), timestamp},
+			[]interface{}{">", fieldRef(idFieldID), afterID},
+		},
+	}
+}
+
+// serverVersion returns the Metabase release, fetched once and then cached.
+func (c *MetabaseV056Client) serverVersion(ctx context.Context) (Version, *v2.RateLimitDescription, error) {
+	c.versionMu.Lock()
+	defer c.versionMu.Unlock()
+
+	if c.version != nil {
+		return *c.version, nil, nil
+	}
+
+	versionInfo, rateLimitDesc, err := c.GetVersion(ctx)
+	if err != nil {
+		return Version{}, rateLimitDesc, err
+	}
+
+	version, err := ParseVersion(versionInfo.Tag)
+	if err != nil {
+		return Version{}, rateLimitDesc, err
+	}
+
+	c.version = &version
+	return version, rateLimitDesc, nil
+}
+
+// fieldRef returns the MBQL reference to the field with the given ID.
+func fieldRef(fieldID int) []interface{} {
+	return []interface{}{"field", fieldID, nil}
 }
 
 func (c *MetabaseV056Client) GetVersion(ctx context.Context) (*VersionInfo, *v2.RateLimitDescription, error) {
@@ -2,13 +2,49 @@ package client
 
 import (
 	"context"
+	"time"
 
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 )
 
 type ClientService interface {
-	ListDatabases(ctx context.Context) ([]*Database, *v2.RateLimitDescription, error)
-	GetDBPermissions(ctx context.Context, dbID string) (map[string]map[string]*GroupPermission, *v2.RateLimitDescription, error)
+	ListUsers(ctx context.Context, opts PageOptions) ([]*User, int, *v2.RateLimitDescription, error)
+	GetUser(ctx context.Context, userID string) (*User, *v2.RateLimitDescription, error)
+	UpdateUser(ctx context.Context, userID string, update *UserUpdate) (*User, *v2.RateLimitDescription, error)
+	CreateUser(ctx context.Context, request *CreateUserRequest) (*User, *v2.RateLimitDescription, error)
+	UpdateUserActiveStatus(ctx context.Context, userID string, active bool) (*User, *v2.RateLimitDescription, error)
+	ListGroups(ctx context.Context) ([]*Group, *v2.RateLimitDescription, error)
+	CreateGroup(ctx context.Context, name string) (*Group, *v2.RateLimitDescription, error)
+	RenameGroup(ctx context.Context, groupID string, name string) (*Group, *v2.RateLimitDescription, error)
+	DeleteGroup(ctx context.Context, groupID string) (*v2.RateLimitDescription, error)
+	ListMemberships(ctx context.Context) (map[string][]*Membership, *v2.RateLimitDescription, error)
+	AddUserToGroup(ctx context.Context, membership *Membership) (*v2.RateLimitDescription, error)
+	RemoveUserFromGroup(ctx context.Context, membershipID string) (*v2.RateLimitDescription, error)
+	SetGroupManager(ctx context.Context, membershipID string, isGroupManager bool) (*v2.RateLimitDescription, error)
+	ListAPIKeys(ctx context.Context) ([]*APIKey, *v2.RateLimitDescription, error)
+	RegenerateAPIKey(ctx context.Context, apiKeyID string) (*RegeneratedAPIKey, *v2.RateLimitDescription, error)
+	DeleteAPIKey(ctx context.Context, apiKeyID string) (*v2.RateLimitDescription, error)
+	ListDatabases(ctx context.Context, opts DatabaseListOptions) ([]*Database, int, *v2.RateLimitDescription, error)
+	ListSchemas(ctx context.Context, dbID string) ([]string, *v2.RateLimitDescription, error)
+	ListTables(ctx context.Context, dbID string, schema string) ([]*Table, *v2.RateLimitDescription, error)
+	GetTable(ctx context.Context, tableID string) (*Table, *v2.RateLimitDescription, error)
+	GetDBPermissions(ctx context.Context, dbID string) (*DBPermissionGraph, *v2.RateLimitDescription, error)
+	GetPermissionGraph(ctx context.Context) (*DBPermissionGraph, *v2.RateLimitDescription, error)
+	UpdatePermissionGraph(ctx context.Context, graph *DBPermissionGraph) (*v2.RateLimitDescription, error)
+	ListSandboxes(ctx context.Context, tableID string) ([]*Sandbox, *v2.RateLimitDescription, error)
+	CreateSandbox(ctx context.Context, sandbox *Sandbox) (*Sandbox, *v2.RateLimitDescription, error)
+	DeleteSandbox(ctx context.Context, sandboxID string) (*v2.RateLimitDescription, error)
+	ListCollections(ctx context.Context) ([]*Collection, *v2.RateLimitDescription, error)
+	ListCollectionItems(ctx context.Context, collectionID int) ([]*CollectionItem, *v2.RateLimitDescription, error)
+	UpdateCollectionItem(ctx context.Context, item *CollectionItem, update *CollectionItemUpdate) (*v2.RateLimitDescription, error)
+	ListDashboards(ctx context.Context, archived bool) ([]*Dashboard, *v2.RateLimitDescription, error)
+	ListCards(ctx context.Context, archived bool) ([]*Card, *v2.RateLimitDescription, error)
+	GetCollectionPermissions(ctx context.Context) (*CollectionPermissionGraph, *v2.RateLimitDescription, error)
+	UpdateCollectionPermissions(ctx context.Context, graph *CollectionPermissionGraph) (*v2.RateLimitDescription, error)
+	GetApplicationPermissions(ctx context.Context) (*ApplicationPermissionGraph, *v2.RateLimitDescription, error)
+	UpdateApplicationPermissions(ctx context.Context, graph *ApplicationPermissionGraph) (*v2.RateLimitDescription, error)
+	ListAuditLogEntries(ctx context.Context, afterTimestamp time.Time, afterID int, limit int) ([]*AuditLogEntry, *v2.RateLimitDescription, error)
 	GetVersion(ctx context.Context) (*VersionInfo, *v2.RateLimitDescription, error)
+	Logout(ctx context.Context) (*v2.RateLimitDescription, error)
 	IsPaidPlan() bool
 }
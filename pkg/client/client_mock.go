@@ -2,29 +2,208 @@ package client
 
 import (
 	"context"
+	"time"
 
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 )
 
 type MockService struct {
-	ListDatabasesFunc    func(ctx context.Context) ([]*Database, *v2.RateLimitDescription, error)
-	GetDBPermissionsFunc func(ctx context.Context, dbID string) (map[string]map[string]*GroupPermission, *v2.RateLimitDescription, error)
-	GetVersionFunc       func(ctx context.Context) (*VersionInfo, *v2.RateLimitDescription, error)
-	IsPaidPlanFunc       func() bool
+	ListUsersFunc                    func(ctx context.Context, opts PageOptions) ([]*User, int, *v2.RateLimitDescription, error)
+	GetUserFunc                      func(ctx context.Context, userID string) (*User, *v2.RateLimitDescription, error)
+	UpdateUserFunc                   func(ctx context.Context, userID string, update *UserUpdate) (*User, *v2.RateLimitDescription, error)
+	CreateUserFunc                   func(ctx context.Context, request *CreateUserRequest) (*User, *v2.RateLimitDescription, error)
+	UpdateUserActiveStatusFunc       func(ctx context.Context, userID string, active bool) (*User, *v2.RateLimitDescription, error)
+	ListGroupsFunc                   func(ctx context.Context) ([]*Group, *v2.RateLimitDescription, error)
+	CreateGroupFunc                  func(ctx context.Context, name string) (*Group, *v2.RateLimitDescription, error)
+	RenameGroupFunc                  func(ctx context.Context, groupID string, name string) (*Group, *v2.RateLimitDescription, error)
+	DeleteGroupFunc                  func(ctx context.Context, groupID string) (*v2.RateLimitDescription, error)
+	ListMembershipsFunc              func(ctx context.Context) (map[string][]*Membership, *v2.RateLimitDescription, error)
+	AddUserToGroupFunc               func(ctx context.Context, membership *Membership) (*v2.RateLimitDescription, error)
+	RemoveUserFromGroupFunc          func(ctx context.Context, membershipID string) (*v2.RateLimitDescription, error)
+	SetGroupManagerFunc              func(ctx context.Context, membershipID string, isGroupManager bool) (*v2.RateLimitDescription, error)
+	ListAPIKeysFunc                  func(ctx context.Context) ([]*APIKey, *v2.RateLimitDescription, error)
+	RegenerateAPIKeyFunc             func(ctx context.Context, apiKeyID string) (*RegeneratedAPIKey, *v2.RateLimitDescription, error)
+	DeleteAPIKeyFunc                 func(ctx context.Context, apiKeyID string) (*v2.RateLimitDescription, error)
+	ListDatabasesFunc                func(ctx context.Context, opts DatabaseListOptions) ([]*Database, int, *v2.RateLimitDescription, error)
+	ListSchemasFunc                  func(ctx context.Context, dbID string) ([]string, *v2.RateLimitDescription, error)
+	ListTablesFunc                   func(ctx context.Context, dbID string, schema string) ([]*Table, *v2.RateLimitDescription, error)
+	GetTableFunc                     func(ctx context.Context, tableID string) (*Table, *v2.RateLimitDescription, error)
+	GetDBPermissionsFunc             func(ctx context.Context, dbID string) (*DBPermissionGraph, *v2.RateLimitDescription, error)
+	GetPermissionGraphFunc           func(ctx context.Context) (*DBPermissionGraph, *v2.RateLimitDescription, error)
+	UpdatePermissionGraphFunc        func(ctx context.Context, graph *DBPermissionGraph) (*v2.RateLimitDescription, error)
+	ListSandboxesFunc                func(ctx context.Context, tableID string) ([]*Sandbox, *v2.RateLimitDescription, error)
+	CreateSandboxFunc                func(ctx context.Context, sandbox *Sandbox) (*Sandbox, *v2.RateLimitDescription, error)
+	DeleteSandboxFunc                func(ctx context.Context, sandboxID string) (*v2.RateLimitDescription, error)
+	ListCollectionsFunc              func(ctx context.Context) ([]*Collection, *v2.RateLimitDescription, error)
+	ListCollectionItemsFunc          func(ctx context.Context, collectionID int) ([]*CollectionItem, *v2.RateLimitDescription, error)
+	UpdateCollectionItemFunc         func(ctx context.Context, item *CollectionItem, update *CollectionItemUpdate) (*v2.RateLimitDescription, error)
+	ListDashboardsFunc               func(ctx context.Context, archived bool) ([]*Dashboard, *v2.RateLimitDescription, error)
+	ListCardsFunc                    func(ctx context.Context, archived bool) ([]*Card, *v2.RateLimitDescription, error)
+	GetCollectionPermissionsFunc     func(ctx context.Context) (*CollectionPermissionGraph, *v2.RateLimitDescription, error)
+	UpdateCollectionPermissionsFunc  func(ctx context.Context, graph *CollectionPermissionGraph) (*v2.RateLimitDescription, error)
+	GetApplicationPermissionsFunc    func(ctx context.Context) (*ApplicationPermissionGraph, *v2.RateLimitDescription, error)
+	UpdateApplicationPermissionsFunc func(ctx context.Context, graph *ApplicationPermissionGraph) (*v2.RateLimitDescription, error)
+	ListAuditLogEntriesFunc          func(ctx context.Context, afterTimestamp time.Time, afterID int, limit int) ([]*AuditLogEntry, *v2.RateLimitDescription, error)
+	GetVersionFunc                   func(ctx context.Context) (*VersionInfo, *v2.RateLimitDescription, error)
+	LogoutFunc                       func(ctx context.Context) (*v2.RateLimitDescription, error)
+	IsPaidPlanFunc                   func() bool
 }
 
-func (m *MockService) ListDatabases(ctx context.Context) ([]*Database, *v2.RateLimitDescription, error) {
-	return m.ListDatabasesFunc(ctx)
+func (m *MockService) ListUsers(ctx context.Context, opts PageOptions) ([]*User, int, *v2.RateLimitDescription, error) {
+	return m.ListUsersFunc(ctx, opts)
 }
 
-func (m *MockService) GetDBPermissions(ctx context.Context, dbID string) (map[string]map[string]*GroupPermission, *v2.RateLimitDescription, error) {
+func (m *MockService) GetUser(ctx context.Context, userID string) (*User, *v2.RateLimitDescription, error) {
+	return m.GetUserFunc(ctx, userID)
+}
+
+func (m *MockService) UpdateUser(ctx context.Context, userID string, update *UserUpdate) (*User, *v2.RateLimitDescription, error) {
+	return m.UpdateUserFunc(ctx, userID, update)
+}
+
+func (m *MockService) CreateUser(ctx context.Context, request *CreateUserRequest) (*User, *v2.RateLimitDescription, error) {
+	return m.CreateUserFunc(ctx, request)
+}
+
+func (m *MockService) UpdateUserActiveStatus(ctx context.Context, userID string, active bool) (*User, *v2.RateLimitDescription, error) {
+	return m.UpdateUserActiveStatusFunc(ctx, userID, active)
+}
+
+func (m *MockService) ListGroups(ctx context.Context) ([]*Group, *v2.RateLimitDescription, error) {
+	return m.ListGroupsFunc(ctx)
+}
+
+func (m *MockService) CreateGroup(ctx context.Context, name string) (*Group, *v2.RateLimitDescription, error) {
+	return m.CreateGroupFunc(ctx, name)
+}
+
+func (m *MockService) RenameGroup(ctx context.Context, groupID string, name string) (*Group, *v2.RateLimitDescription, error) {
+	return m.RenameGroupFunc(ctx, groupID, name)
+}
+
+func (m *MockService) DeleteGroup(ctx context.Context, groupID string) (*v2.RateLimitDescription, error) {
+	return m.DeleteGroupFunc(ctx, groupID)
+}
+
+func (m *MockService) ListMemberships(ctx context.Context) (map[string][]*Membership, *v2.RateLimitDescription, error) {
+	return m.ListMembershipsFunc(ctx)
+}
+
+func (m *MockService) AddUserToGroup(ctx context.Context, membership *Membership) (*v2.RateLimitDescription, error) {
+	return m.AddUserToGroupFunc(ctx, membership)
+}
+
+func (m *MockService) RemoveUserFromGroup(ctx context.Context, membershipID string) (*v2.RateLimitDescription, error) {
+	return m.RemoveUserFromGroupFunc(ctx, membershipID)
+}
+
+func (m *MockService) SetGroupManager(ctx context.Context, membershipID string, isGroupManager bool) (*v2.RateLimitDescription, error) {
+	return m.SetGroupManagerFunc(ctx, membershipID, isGroupManager)
+}
+
+func (m *MockService) ListAPIKeys(ctx context.Context) ([]*APIKey, *v2.RateLimitDescription, error) {
+	return m.ListAPIKeysFunc(ctx)
+}
+
+func (m *MockService) RegenerateAPIKey(ctx context.Context, apiKeyID string) (*RegeneratedAPIKey, *v2.RateLimitDescription, error) {
+	return m.RegenerateAPIKeyFunc(ctx, apiKeyID)
+}
+
+func (m *MockService) DeleteAPIKey(ctx context.Context, apiKeyID string) (*v2.RateLimitDescription, error) {
+	return m.DeleteAPIKeyFunc(ctx, apiKeyID)
+}
+
+func (m *MockService) ListDatabases(ctx context.Context, opts DatabaseListOptions) ([]*Database, int, *v2.RateLimitDescription, error) {
+	return m.ListDatabasesFunc(ctx, opts)
+}
+
+func (m *MockService) ListSchemas(ctx context.Context, dbID string) ([]string, *v2.RateLimitDescription, error) {
+	return m.ListSchemasFunc(ctx, dbID)
+}
+
+func (m *MockService) ListTables(ctx context.Context, dbID string, schema string) ([]*Table, *v2.RateLimitDescription, error) {
+	return m.ListTablesFunc(ctx, dbID, schema)
+}
+
+func (m *MockService) GetTable(ctx context.Context, tableID string) (*Table, *v2.RateLimitDescription, error) {
+	return m.GetTableFunc(ctx, tableID)
+}
+
+func (m *MockService) GetDBPermissions(ctx context.Context, dbID string) (*DBPermissionGraph, *v2.RateLimitDescription, error) {
 	return m.GetDBPermissionsFunc(ctx, dbID)
 }
 
+func (m *MockService) GetPermissionGraph(ctx context.Context) (*DBPermissionGraph, *v2.RateLimitDescription, error) {
+	return m.GetPermissionGraphFunc(ctx)
+}
+
+func (m *MockService) UpdatePermissionGraph(ctx context.Context, graph *DBPermissionGraph) (*v2.RateLimitDescription, error) {
+	return m.UpdatePermissionGraphFunc(ctx, graph)
+}
+
+func (m *MockService) ListSandboxes(ctx context.Context, tableID string) ([]*Sandbox, *v2.RateLimitDescription, error) {
+	return m.ListSandboxesFunc(ctx, tableID)
+}
+
+func (m *MockService) CreateSandbox(ctx context.Context, sandbox *Sandbox) (*Sandbox, *v2.RateLimitDescription, error) {
+	return m.CreateSandboxFunc(ctx, sandbox)
+}
+
+func (m *MockService) DeleteSandbox(ctx context.Context, sandboxID string) (*v2.RateLimitDescription, error) {
+	return m.DeleteSandboxFunc(ctx, sandboxID)
+}
+
+func (m *MockService) ListCollections(ctx context.Context) ([]*Collection, *v2.RateLimitDescription, error) {
+	return m.ListCollectionsFunc(ctx)
+}
+
+func (m *MockService) ListCollectionItems(ctx context.Context, collectionID int) ([]*CollectionItem, *v2.RateLimitDescription, error) {
+	return m.ListCollectionItemsFunc(ctx, collectionID)
+}
+
+func (m *MockService) UpdateCollectionItem(ctx context.Context, item *CollectionItem, update *CollectionItemUpdate) (*v2.RateLimitDescription, error) {
+	return m.UpdateCollectionItemFunc(ctx, item, update)
+}
+
+func (m *MockService) ListDashboards(ctx context.Context, archived bool) ([]*Dashboard, *v2.RateLimitDescription, error) {
+	return m.ListDashboardsFunc(ctx, archived)
+}
+
+func (m *MockService) ListCards(ctx context.Context, archived bool) ([]*Card, *v2.RateLimitDescription, error) {
+	return m.ListCardsFunc(ctx, archived)
+}
+
+func (m *MockService) GetCollectionPermissions(ctx context.Context) (*CollectionPermissionGraph, *v2.RateLimitDescription, error) {
+	return m.GetCollectionPermissionsFunc(ctx)
+}
+
+func (m *MockService) UpdateCollectionPermissions(ctx context.Context, graph *CollectionPermissionGraph) (*v2.RateLimitDescription, error) {
+	return m.UpdateCollectionPermissionsFunc(ctx, graph)
+}
+
+func (m *MockService) GetApplicationPermissions(ctx context.Context) (*ApplicationPermissionGraph, *v2.RateLimitDescription, error) {
+	return m.GetApplicationPermissionsFunc(ctx)
+}
+
+func (m *MockService) UpdateApplicationPermissions(ctx context.Context, graph *ApplicationPermissionGraph) (*v2.RateLimitDescription, error) {
+	return m.UpdateApplicationPermissionsFunc(ctx, graph)
+}
+
+func (m *MockService) ListAuditLogEntries(ctx context.Context, afterTimestamp time.Time, afterID int, limit int) ([]*AuditLogEntry, *v2.RateLimitDescription, error) {
+	return m.ListAuditLogEntriesFunc(ctx, afterTimestamp, afterID, limit)
+}
+
 func (m *MockService) GetVersion(ctx context.Context) (*VersionInfo, *v2.RateLimitDescription, error) {
 	return m.GetVersionFunc(ctx)
 }
 
+func (m *MockService) Logout(ctx context.Context) (*v2.RateLimitDescription, error) {
+	if m.LogoutFunc != nil {
+		return m.LogoutFunc(ctx)
+	}
+	return nil, nil
+}
+
 func (m *MockService) IsPaidPlan() bool {
 	if m.IsPaidPlanFunc != nil {
 		return m.IsPaidPlanFunc()
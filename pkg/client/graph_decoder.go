@@ -0,0 +1,128 @@
+package client
+
+import (
+	"encoding/json"
+)
+
+const (
+	legacyNativeWrite      = "write"
+	legacyAccessAll        = "all"
+	legacyAccessSegmented  = "segmented"
+	legacyAccessBlock      = "block"
+	legacyTableDepth       = 2
+	legacyTableQueryAccess = "query"
+)
+
+// groupPermissionDecoder decodes the permission of a group on a database as sent by a Metabase release.
+type groupPermissionDecoder func(data []byte) (*GroupPermission, error)
+
+// groupPermissionDecoderFor returns the decoder of the data permission graph of the release.
+func groupPermissionDecoderFor(version Version) groupPermissionDecoder {
+	if version.Minor < viewDataGraphMinor {
+		return decodeLegacyGroupPermission
+	}
+	return decodeGroupPermission
+}
+
+func decodeGroupPermission(data []byte) (*GroupPermission, error) {
+	var permission GroupPermission
+	if err := json.Unmarshal(data, &permission); err != nil {
+		return nil, err
+	}
+	return &permission, nil
+}
+
+// legacyGroupPermission is the permission of a group on a database before Metabase 0.50, where data access is
+// a single dimension holding native query access and per-schema access.
+/* Example JSON version 0.49:
+{
+    "data": {"native": "write", "schemas": "all"},
+    "download": {"native": "full", "schemas": "full"}
+}
+When permissions are granular, schemas is an object keyed by schema and then by table ID, where a table value is
+either a string or an object like {"read": "all", "query": "segmented"}.
+*/
+type legacyGroupPermission struct {
+	Data      *legacyDataPermission `json:"data"`
+	Download  *SchemasPermission    `json:"download"`
+	DataModel *SchemasPermission    `json:"data-model"`
+	Details   string                `json:"details"`
+}
+
+type legacyDataPermission struct {
+	Native  string           `json:"native"`
+	Schemas *PermissionValue `json:"schemas"`
+}
+
+// decodeLegacyGroupPermission decodes a legacy permission into the view-data and create-queries dimensions the
+// way Metabase 0.50 migrated them.
+func decodeLegacyGroupPermission(data []byte) (*GroupPermission, error) {
+	var legacy legacyGroupPermission
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return nil, err
+	}
+
+	permission := &GroupPermission{
+		Download:  legacy.Download,
+		DataModel: legacy.DataModel,
+		Details:   legacy.Details,
+	}
+
+	if legacy.Data == nil {
+		return permission, nil
+	}
+
+	permission.ViewData = convertLegacySchemas(legacy.Data.Schemas, 0, legacyViewDataLevel)
+	if legacy.Data.Native == legacyNativeWrite {
+		permission.CreateQueries = PermissionLevel("query-builder-and-native")
+	} else {
+		permission.CreateQueries = convertLegacySchemas(legacy.Data.Schemas, 0, legacyCreateQueriesLevel)
+	}
+
+	return permission, nil
+}
+
+// convertLegacySchemas converts each level of a legacy schemas value with convert. depth is 0 for the database,
+// 1 for a schema and 2 for a table.
+func convertLegacySchemas(value *PermissionValue, depth int, convert func(level string) string) *PermissionValue {
+	if value == nil {
+		return nil
+	}
+
+	if value.Children == nil {
+		return PermissionLevel(convert(value.Level))
+	}
+
+	// Tables may hold a {"read", "query"} object instead of a level.
+	if depth >= legacyTableDepth {
+		return PermissionLevel(convert(value.Child(legacyTableQueryAccess).GetLevel()))
+	}
+
+	children := make(map[string]*PermissionValue, len(value.Children))
+	for key, child := range value.Children {
+		children[key] = convertLegacySchemas(child, depth+1, convert)
+	}
+	return &PermissionValue{Children: children}
+}
+
+func legacyViewDataLevel(level string) string {
+	switch level {
+	case legacyAccessAll:
+		return "unrestricted"
+	case legacyAccessSegmented:
+		return "sandboxed"
+	case legacyAccessBlock:
+		return "blocked"
+	default:
+		return "legacy-no-self-service"
+	}
+}
+
+func legacyCreateQueriesLevel(level string) string {
+	switch level {
+	case legacyAccessAll, legacyAccessSegmented:
+		return "query-builder"
+	default:
+		return "no"
+	}
+}
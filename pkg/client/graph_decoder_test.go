@@ -0,0 +1,55 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersion(t *testing.T) {
+	version, err := ParseVersion("v1.56.3.1")
+	require.NoError(t, err)
+	require.Equal(t, Version{Major: 1, Minor: 56, Patch: 3}, version)
+	require.True(t, version.SameRelease(LatestTestedVersion))
+
+	version, err = ParseVersion("v0.57.0-RC1")
+	require.NoError(t, err)
+	require.Equal(t, 1, version.Compare(LatestTestedVersion))
+
+	_, err = ParseVersion("vUNKNOWN")
+	require.Error(t, err)
+}
+
+func TestDecodeLegacyGroupPermission(t *testing.T) {
+	t.Run("should map native write to query builder and native", func(t *testing.T) {
+		permission, err := groupPermissionDecoderFor(Version{Minor: 49})([]byte(`{
+			"data": {"native": "write", "schemas": "all"},
+			"download": {"schemas": "full"}
+		}`))
+		require.NoError(t, err)
+		require.Equal(t, "unrestricted", permission.ViewData.GetLevel())
+		require.Equal(t, "query-builder-and-native", permission.CreateQueries.GetLevel())
+		require.Equal(t, "full", permission.Download.Schemas.GetLevel())
+	})
+
+	t.Run("should map granular schemas and sandboxed tables", func(t *testing.T) {
+		permission, err := decodeLegacyGroupPermission([]byte(`{
+			"data": {"schemas": {"PUBLIC": {"11": "all", "12": {"read": "all", "query": "segmented"}}, "ANALYTICS": "none"}}
+		}`))
+		require.NoError(t, err)
+
+		public := permission.ViewData.Child("PUBLIC")
+		require.Equal(t, "unrestricted", public.Child("11").GetLevel())
+		require.Equal(t, "sandboxed", public.Child("12").GetLevel())
+		require.Equal(t, "legacy-no-self-service", permission.ViewData.Child("ANALYTICS").GetLevel())
+		require.Equal(t, "query-builder", permission.CreateQueries.Child("PUBLIC").Child("12").GetLevel())
+		require.Equal(t, "no", permission.CreateQueries.Child("ANALYTICS").GetLevel())
+	})
+
+	t.Run("should decode the current graph as is from 0.50", func(t *testing.T) {
+		permission, err := groupPermissionDecoderFor(Version{Minor: 50})([]byte(`{"view-data": "blocked", "create-queries": "no"}`))
+		require.NoError(t, err)
+		require.Equal(t, "blocked", permission.ViewData.GetLevel())
+		require.Equal(t, "no", permission.CreateQueries.GetLevel())
+	})
+}
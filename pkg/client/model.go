@@ -1,7 +1,10 @@
 package client
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"time"
 )
 
 type Database struct {
@@ -10,18 +13,449 @@ type Database struct {
 	Description string `json:"description"`
 	Engine      string `json:"engine"`
 	CreatedAt   string `json:"created-at"`
+	// Details are the connection details, like host and dbname, whose keys depend on the engine. Metabase
+	// redacts passwords, but values like connection strings may still hold secrets.
+	Details           map[string]interface{} `json:"details"`
+	IsSample          bool                   `json:"is_sample"`
+	IsAudit           bool                   `json:"is_audit"`
+	InitialSyncStatus string                 `json:"initial_sync_status"`
+	IsFullSync        bool                   `json:"is_full_sync"`
+	IsOnDemand        bool                   `json:"is_on_demand"`
+	// RouterDatabaseID is set on the destination databases of a router database, which picks one of them with
+	// the RouterUserAttribute of the user.
+	RouterDatabaseID    *int    `json:"router_database_id"`
+	RouterUserAttribute *string `json:"router_user_attribute"`
+	// Tables are only returned when listing databases with IncludeTables.
+	Tables []*Table `json:"tables,omitempty"`
 }
 
 type DatabaseAPIResponse struct {
-	Data []*Database `json:"data"`
+	Data  []*Database `json:"data"`
+	Total int         `json:"total"`
 }
 
+// DatabaseListOptions selects the page of databases to list and what to include with them.
+type DatabaseListOptions struct {
+	PageOptions
+	// IncludeTables returns the tables of each database along with it.
+	IncludeTables bool
+	// Saved also returns the virtual database holding the saved questions.
+	Saved bool
+}
+
+// PageOptions selects a page of a list endpoint that supports limit and offset. A zero Limit fetches everything.
+type PageOptions struct {
+	Limit  int
+	Offset int
+}
+
+type User struct {
+	ID                   int        `json:"id"`
+	Email                string     `json:"email"`
+	FirstName            string     `json:"first_name"`
+	LastName             string     `json:"last_name"`
+	Locale               *string    `json:"locale"`
+	IsActive             bool       `json:"is_active"`
+	IsSuperuser          bool       `json:"is_superuser"`
+	LastLogin            *time.Time `json:"last_login"`
+	PersonalCollectionID *int       `json:"personal_collection_id"`
+	// LoginAttributes are set by SSO or by admins, and used by sandboxes and connection impersonation.
+	LoginAttributes map[string]interface{} `json:"login_attributes"`
+}
+
+type UsersAPIResponse struct {
+	Data  []*User `json:"data"`
+	Total int     `json:"total"`
+}
+
+type CreateUserRequest struct {
+	Email     string `json:"email"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Password  string `json:"password"`
+}
+
+// UserUpdate is the body of a user update. Only the fields that are set are changed.
+type UserUpdate struct {
+	FirstName   *string `json:"first_name,omitempty"`
+	LastName    *string `json:"last_name,omitempty"`
+	Email       *string `json:"email,omitempty"`
+	Locale      *string `json:"locale,omitempty"`
+	IsSuperuser *bool   `json:"is_superuser,omitempty"`
+	// LoginAttributes replaces every login attribute of the user. It is a pointer so that an empty map clears them.
+	LoginAttributes *map[string]interface{} `json:"login_attributes,omitempty"`
+}
+
+// The groups that Metabase creates itself. They can be neither renamed nor deleted.
+const (
+	AllUsersGroupID       = 1
+	AdministratorsGroupID = 2
+)
+
+// Group is a permission group.
+type Group struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	MemberCount int    `json:"member_count"`
+}
+
+// GroupRequest is the body of a group creation or rename.
+type GroupRequest struct {
+	Name string `json:"name"`
+}
+
+// Membership is the membership of a user in a group. Group managers only exist on paid plans.
+type Membership struct {
+	MembershipID   int  `json:"membership_id,omitempty"`
+	GroupID        int  `json:"group_id"`
+	UserID         int  `json:"user_id"`
+	IsGroupManager bool `json:"is_group_manager"`
+}
+
+// LoginRequest is the body of a login with the email and password of a user.
+type LoginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type LoginResponse struct {
+	ID string `json:"id"`
+}
+
+// APIKey is an API key of the instance. Each key is backed by a service user that is a member of the key's group.
+type APIKey struct {
+	ID         int          `json:"id"`
+	UserID     int          `json:"user_id"`
+	Name       string       `json:"name"`
+	MaskedKey  string       `json:"masked_key"`
+	Group      *APIKeyGroup `json:"group"`
+	CreatorID  int          `json:"creator_id"`
+	CreatedAt  string       `json:"created_at"`
+	UpdatedAt  string       `json:"updated_at"`
+	LastUsedAt string       `json:"last_used_at"`
+}
+
+type APIKeyGroup struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type RegeneratedAPIKey struct {
+	ID          int    `json:"id"`
+	UnmaskedKey string `json:"unmasked_key"`
+	MaskedKey   string `json:"masked_key"`
+}
+
+type Table struct {
+	ID          int    `json:"id"`
+	DBID        int    `json:"db_id"`
+	Schema      string `json:"schema"`
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	Description string `json:"description"`
+	Active      bool   `json:"active"`
+}
+
+// GroupPermission holds the permissions of a group on a database. Each field is one dimension of the
+// data permission graph; empty fields are omitted so that a partial value can be used to update a single dimension.
 type GroupPermission struct {
-	CreateQueries string `json:"create-queries,omitempty"`
+	ViewData      *PermissionValue   `json:"view-data,omitempty"`
+	CreateQueries *PermissionValue   `json:"create-queries,omitempty"`
+	Download      *SchemasPermission `json:"download,omitempty"`
+	DataModel     *SchemasPermission `json:"data-model,omitempty"`
+	Details       string             `json:"details,omitempty"`
 }
 
+// SchemasPermission is a permission that Metabase nests under a "schemas" key, like download and data-model.
+type SchemasPermission struct {
+	Schemas *PermissionValue `json:"schemas,omitempty"`
+}
+
+// PermissionValue is a level of the data permission graph. Metabase sends it either as a single string that
+// applies to the whole database or, when permissions are granular, as an object keyed by schema name whose
+// values are in turn a level string or an object keyed by table ID.
+type PermissionValue struct {
+	Level    string
+	Children map[string]*PermissionValue
+}
+
+// PermissionLevel returns a PermissionValue that applies level to the whole database.
+func PermissionLevel(level string) *PermissionValue {
+	return &PermissionValue{Level: level}
+}
+
+// GetLevel returns the level of the value, which is empty when the value is granular or missing.
+func (v *PermissionValue) GetLevel() string {
+	if v == nil {
+		return ""
+	}
+	return v.Level
+}
+
+// Child returns the value for a schema name or table ID, or nil if the value is not granular.
+func (v *PermissionValue) Child(key string) *PermissionValue {
+	if v == nil {
+		return nil
+	}
+	return v.Children[key]
+}
+
+func (v *PermissionValue) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		return json.Unmarshal(data, &v.Level)
+	}
+	return json.Unmarshal(data, &v.Children)
+}
+
+func (v PermissionValue) MarshalJSON() ([]byte, error) {
+	if v.Children != nil {
+		return json.Marshal(v.Children)
+	}
+	return json.Marshal(v.Level)
+}
+
+// DBPermissionGraph is the data permission graph keyed by group ID and then database ID.
+// It is used both to read the graph and, restricted to the entries to change, to update it.
 type DBPermissionGraph struct {
-	Groups map[string]map[string]*GroupPermission `json:"groups"`
+	Revision int                                    `json:"revision"`
+	Groups   map[string]map[string]*GroupPermission `json:"groups"`
+}
+
+// Sandbox is a group table access policy of a paid plan: the rows of the table that the group sees are filtered
+// by the card (a saved question) when CardID is set, and by the login attributes of the user mapped to the table
+// fields in AttributeRemappings.
+type Sandbox struct {
+	ID                  int                    `json:"id,omitempty"`
+	GroupID             int                    `json:"group_id"`
+	TableID             int                    `json:"table_id"`
+	CardID              *int                   `json:"card_id"`
+	AttributeRemappings map[string]interface{} `json:"attribute_remappings"`
+}
+
+// CollectionID is the ID of a collection: a number, or "root" for the root collection.
+type CollectionID string
+
+const RootCollectionID CollectionID = "root"
+
+func (id *CollectionID) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*id = CollectionID(s)
+		return nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	*id = CollectionID(n.String())
+	return nil
+}
+
+type Collection struct {
+	ID              CollectionID `json:"id"`
+	Name            string       `json:"name"`
+	Description     string       `json:"description"`
+	Location        string       `json:"location"`
+	PersonalOwnerID *int         `json:"personal_owner_id"`
+	Archived        bool         `json:"archived"`
+}
+
+// CollectionItem is an item of a collection. Model is "card", "dataset" (a model), "metric", "dashboard"
+// or "collection" for the items that can be archived and moved.
+type CollectionItem struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Model string `json:"model"`
+}
+
+type CollectionItemsAPIResponse struct {
+	Data  []*CollectionItem `json:"data"`
+	Total int               `json:"total"`
+}
+
+// CollectionItemUpdate archives an item of a collection, or moves it to another collection, possibly the root
+// collection, when CollectionID is set.
+type CollectionItemUpdate struct {
+	Archived     bool
+	CollectionID CollectionID
+}
+
+// Dashboard is a dashboard of a collection. CollectionID is nil for dashboards of the root collection.
+type Dashboard struct {
+	ID              int     `json:"id"`
+	Name            string  `json:"name"`
+	Description     string  `json:"description"`
+	CollectionID    *int    `json:"collection_id"`
+	CreatorID       int     `json:"creator_id"`
+	Archived        bool    `json:"archived"`
+	PublicUUID      *string `json:"public_uuid"`
+	EnableEmbedding bool    `json:"enable_embedding"`
+	CreatedAt       string  `json:"created_at"`
+	UpdatedAt       string  `json:"updated_at"`
+}
+
+// Card is a saved question, model or metric of a collection. CollectionID is nil for cards of the root collection.
+type Card struct {
+	ID              int     `json:"id"`
+	Name            string  `json:"name"`
+	Description     string  `json:"description"`
+	Type            string  `json:"type"`
+	CollectionID    *int    `json:"collection_id"`
+	DatabaseID      *int    `json:"database_id"`
+	CreatorID       int     `json:"creator_id"`
+	Archived        bool    `json:"archived"`
+	PublicUUID      *string `json:"public_uuid"`
+	EnableEmbedding bool    `json:"enable_embedding"`
+	CreatedAt       string  `json:"created_at"`
+	UpdatedAt       string  `json:"updated_at"`
+}
+
+// CollectionPermissionGraph holds the collection permission of each group keyed by group ID and then
+// collection ID. Values are "read", "write" or "none".
+type CollectionPermissionGraph struct {
+	Revision int                          `json:"revision"`
+	Groups   map[string]map[string]string `json:"groups"`
+}
+
+// collectionGraphUpdate is the body of a collection graph update. Unlike the data permission graph,
+// skip_graph is read from the body and makes Metabase answer with the new revision only.
+type collectionGraphUpdate struct {
+	*CollectionPermissionGraph
+	SkipGraph bool `json:"skip_graph,omitempty"`
+}
+
+// ApplicationPermissionGraph holds the application permissions of each group keyed by group ID and then
+// permission ("setting", "monitoring" or "subscription"). Values are "yes" or "no".
+type ApplicationPermissionGraph struct {
+	Revision int                          `json:"revision"`
+	Groups   map[string]map[string]string `json:"groups"`
+}
+
+// DatabaseMetadata is the metadata of a database, with its tables and their fields.
+type DatabaseMetadata struct {
+	ID     int              `json:"id"`
+	Tables []*TableMetadata `json:"tables"`
+}
+
+func (m *DatabaseMetadata) Table(name string) *TableMetadata {
+	for _, table := range m.Tables {
+		if table.Name == name {
+			return table
+		}
+	}
+	return nil
+}
+
+type TableMetadata struct {
+	ID     int              `json:"id"`
+	Name   string           `json:"name"`
+	Fields []*FieldMetadata `json:"fields"`
+}
+
+func (t *TableMetadata) Field(name string) *FieldMetadata {
+	for _, field := range t.Fields {
+		if field.Name == name {
+			return field
+		}
+	}
+	return nil
+}
+
+type FieldMetadata struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// DatasetQuery is the body of an ad hoc query. Query holds the MBQL query of query builder queries.
+type DatasetQuery struct {
+	Database int                    `json:"database"`
+	Type     string                 `json:"type"`
+	Query    map[string]interface{} `json:"query"`
+}
+
+type DatasetResponse struct {
+	Status string      `json:"status"`
+	Error  string      `json:"error"`
+	Data   DatasetData `json:"data"`
+}
+
+type DatasetData struct {
+	Cols []*DatasetColumn `json:"cols"`
+	Rows [][]interface{}  `json:"rows"`
+}
+
+type DatasetColumn struct {
+	Name string `json:"name"`
+}
+
+// AuditLogEntry is an entry of the audit log of paid plans. UserID is the user that caused the event, and
+// EntityType and EntityID identify the object it is about, e.g. "user" and the ID of the deactivated user.
+type AuditLogEntry struct {
+	ID         int
+	Topic      string
+	Timestamp  time.Time
+	UserID     *int
+	EntityType string
+	EntityID   *int
+}
+
+// AuditLogEntries maps the rows of an audit log query to entries, reading each value from its column.
+func (r *DatasetResponse) AuditLogEntries() ([]*AuditLogEntry, error) {
+	columns := make(map[string]int, len(r.Data.Cols))
+	for i, col := range r.Data.Cols {
+		columns[col.Name] = i
+	}
+
+	value := func(row []interface{}, name string) interface{} {
+		i, ok := columns[name]
+		if !ok || i >= len(row) {
+			return nil
+		}
+		return row[i]
+	}
+
+	intValue := func(row []interface{}, name string) *int {
+		n, ok := value(row, name).(float64)
+		if !ok {
+			return nil
+		}
+		i := int(n)
+		return &i
+	}
+
+	entries := make([]*AuditLogEntry, 0, len(r.Data.Rows))
+	for _, row := range r.Data.Rows {
+		id := intValue(row, "id")
+		if id == nil {
+			return nil, fmt.Errorf("audit log entry without id")
+		}
+
+		rawTimestamp, _ := value(row, "timestamp").(string)
+		timestamp, err := time.Parse(time.RFC3339Nano, rawTimestamp)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp of audit log entry %d: %w", *id, err)
+		}
+
+		topic, _ := value(row, "topic").(string)
+		entityType, _ := value(row, "entity_type").(string)
+
+		entries = append(entries, &AuditLogEntry{
+			ID:         *id,
+			Topic:      topic,
+			Timestamp:  timestamp,
+			UserID:     intValue(row, "user_id"),
+			EntityType: entityType,
+			EntityID:   intValue(row, "entity_id"),
+		})
+	}
+
+	return entries, nil
 }
 
 // VersionInfo represents the version information.
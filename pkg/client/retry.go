@@ -0,0 +1,170 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// maxRetryBackoff caps the delay between two attempts of a request. A Retry-After above it is left to the caller,
+// which gets the delay in the rate limit description.
+const maxRetryBackoff = 30 * time.Second
+
+// minRetryBackoff is the shortest initial backoff of a policy that retries, so that a zero backoff does not send
+// the retries to a struggling Metabase back to back.
+const minRetryBackoff = 100 * time.Millisecond
+
+// APIError is returned when a request to Metabase fails, either with an error status or without a response.
+// Retryable tells whether the same request may succeed later, like when Metabase is restarting or a proxy
+// throttles requests.
+type APIError struct {
+	// StatusCode is zero when no response was received.
+	StatusCode int
+	Message    string
+	Retryable  bool
+	// RetryAfter is the delay asked by the Retry-After header, if any.
+	RetryAfter time.Duration
+	// Err is the error of the HTTP client, if any. For error statuses, it carries the gRPC code and rate limit
+	// details that the SDK retries on.
+	Err error
+}
+
+func (e *APIError) Error() string {
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("metabase API request failed: %s", e.Message)
+	}
+	return fmt.Sprintf("metabase API error: status %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// Is matches the errors of the statuses that callers handle, so that errors.Is(err, ErrConflict) keeps working.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	default:
+		return false
+	}
+}
+
+// isRetryableStatus reports whether a request that failed with the status may succeed if sent again: 429 comes
+// from throttling, and 502 to 504 from a Metabase that is restarting or overloaded.
+func isRetryableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// parseRetryAfter returns the delay of a Retry-After header, given either in seconds or as an HTTP date.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0)
+	}
+
+	return 0
+}
+
+// withRetryAfter marks the rate limit as exceeded until the delay of the Retry-After header is over, so that the
+// SDK waits as long as Metabase or the proxy in front of it asked.
+func withRetryAfter(rateLimitData *v2.RateLimitDescription, retryAfter time.Duration) {
+	rateLimitData.SetStatus(v2.RateLimitDescription_STATUS_OVERLIMIT)
+	rateLimitData.SetRemaining(0)
+	rateLimitData.SetResetAt(timestamppb.New(time.Now().Add(retryAfter)))
+}
+
+// RetryPolicy selects how often idempotent requests are retried after a retryable error. Each delay doubles the
+// previous one, up to 30 seconds, and is jittered so that concurrent requests do not retry all at once. The zero
+// value disables retries.
+type RetryPolicy struct {
+	MaxRetries     int
+	InitialBackoff time.Duration
+}
+
+// validate rejects negative retries or backoffs and raises the initial backoff of a policy that retries to
+// minRetryBackoff.
+func (p RetryPolicy) validate() (RetryPolicy, error) {
+	if p.MaxRetries < 0 {
+		return p, fmt.Errorf("baton-metabase-v056: the number of retries must not be negative, got %d", p.MaxRetries)
+	}
+	if p.InitialBackoff < 0 {
+		return p, fmt.Errorf("baton-metabase-v056: the initial retry backoff must not be negative, got %s", p.InitialBackoff)
+	}
+	if p.MaxRetries > 0 {
+		p.InitialBackoff = max(p.InitialBackoff, minRetryBackoff)
+	}
+	return p, nil
+}
+
+// retry sends a request until it succeeds, fails with an error that is not retryable, or runs out of retries.
+func (c *MetabaseV056Client) retry(
+	ctx context.Context,
+	send func() (*http.Header, *v2.RateLimitDescription, error),
+) (*http.Header, *v2.RateLimitDescription, error) {
+	backoff := c.retryPolicy.InitialBackoff
+
+	for attempt := 1; ; attempt++ {
+		header, rateLimitDesc, err := send()
+
+		var apiErr *APIError
+		if err == nil || attempt > c.retryPolicy.MaxRetries || !errors.As(err, &apiErr) || !apiErr.Retryable {
+			return header, rateLimitDesc, err
+		}
+
+		delay := jitter(backoff)
+		if apiErr.RetryAfter > 0 {
+			if apiErr.RetryAfter > maxRetryBackoff {
+				return header, rateLimitDesc, err
+			}
+			delay = apiErr.RetryAfter
+		}
+
+		ctxzap.Extract(ctx).Debug("retrying Metabase request",
+			zap.Int("attempt", attempt),
+			zap.Duration("delay", delay),
+			zap.Error(err),
+		)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return header, rateLimitDesc, errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+
+		backoff = min(backoff*2, maxRetryBackoff)
+	}
+}
+
+// jitter returns a delay between half of the backoff and the whole backoff.
+func jitter(backoff time.Duration) time.Duration {
+	if backoff <= 1 {
+		return backoff
+	}
+	return backoff/2 + rand.N(backoff/2)
+}
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/stretchr/testify/require"
+)
+
+// newRetryTestServer answers every request with the given statuses in turn, then with the version setting. It
+// returns the number of requests so far.
+func newRetryTestServer(t *testing.T, header http.Header, statuses ...int) (*httptest.Server, *int) {
+	requests := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests <= len(statuses) {
+			for name, values := range header {
+				w.Header()[name] = values
+			}
+			w.WriteHeader(statuses[requests-1])
+			return
+		}
+		_ = json.NewEncoder(w).Encode(VersionInfo{Tag: "v0.56.3"})
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &requests
+}
+
+func TestRetry(t *testing.T) {
+	ctx := context.Background()
+	credentials := Credentials{APIKey: "some-api-key"}
+	retryPolicy := RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond}
+
+	t.Run("should retry reads while Metabase is unavailable", func(t *testing.T) {
+		server, requests := newRetryTestServer(t, nil, http.StatusBadGateway, http.StatusServiceUnavailable)
+		c, err := NewV056Client(ctx, server.URL, credentials, false, retryPolicy)
+		require.NoError(t, err)
+
+		version, _, err := c.GetVersion(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "v0.56.3", version.Tag)
+		require.Equal(t, 3, *requests)
+	})
+
+	t.Run("should give up after the last retry", func(t *testing.T) {
+		server, requests := newRetryTestServer(t, nil, http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+		c, err := NewV056Client(ctx, server.URL, credentials, false, retryPolicy)
+		require.NoError(t, err)
+
+		_, _, err = c.GetVersion(ctx)
+		var apiErr *APIError
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+		require.True(t, apiErr.Retryable)
+		require.Equal(t, 3, *requests)
+	})
+
+	t.Run("should not retry writes", func(t *testing.T) {
+		server, requests := newRetryTestServer(t, nil, http.StatusServiceUnavailable)
+		c, err := NewV056Client(ctx, server.URL, credentials, false, retryPolicy)
+		require.NoError(t, err)
+
+		_, _, err = c.CreateGroup(ctx, "Analysts")
+		require.Error(t, err)
+		require.Equal(t, 1, *requests)
+	})
+
+	t.Run("should not retry errors that are not transient", func(t *testing.T) {
+		server, requests := newRetryTestServer(t, nil, http.StatusConflict)
+		c, err := NewV056Client(ctx, server.URL, credentials, false, retryPolicy)
+		require.NoError(t, err)
+
+		_, _, err = c.GetVersion(ctx)
+		require.ErrorIs(t, err, ErrConflict)
+		require.Equal(t, 1, *requests)
+	})
+
+	t.Run("should report Retry-After in the rate limit description", func(t *testing.T) {
+		server, _ := newRetryTestServer(t, http.Header{"Retry-After": {"120"}}, http.StatusServiceUnavailable)
+		c, err := NewV056Client(ctx, server.URL, credentials, false, retryPolicy)
+		require.NoError(t, err)
+
+		_, rateLimitDesc, err := c.GetVersion(ctx)
+		var apiErr *APIError
+		require.True(t, errors.As(err, &apiErr))
+		require.Equal(t, 120*time.Second, apiErr.RetryAfter)
+		require.Equal(t, v2.RateLimitDescription_STATUS_OVERLIMIT, rateLimitDesc.GetStatus())
+		require.WithinDuration(t, time.Now().Add(120*time.Second), rateLimitDesc.GetResetAt().AsTime(), 5*time.Second)
+	})
+
+	t.Run("should reject a negative retry policy", func(t *testing.T) {
+		_, err := NewV056Client(ctx, "https://metabase-example", credentials, false, RetryPolicy{MaxRetries: -1})
+		require.Error(t, err)
+
+		_, err = NewV056Client(ctx, "https://metabase-example", credentials, false, RetryPolicy{MaxRetries: 2, InitialBackoff: -time.Second})
+		require.Error(t, err)
+	})
+
+	t.Run("should wait between retries even without an initial backoff", func(t *testing.T) {
+		server, requests := newRetryTestServer(t, nil, http.StatusServiceUnavailable)
+		c, err := NewV056Client(ctx, server.URL, credentials, false, RetryPolicy{MaxRetries: 1})
+		require.NoError(t, err)
+
+		start := time.Now()
+		_, _, err = c.GetVersion(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 2, *requests)
+		require.GreaterOrEqual(t, time.Since(start), minRetryBackoff/2)
+	})
+}
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newSessionTestServer serves the session endpoints, plus the version setting and the groups, which only accept
+// the current session. It returns the current session and the number of logins so far.
+func newSessionTestServer(t *testing.T) (*httptest.Server, *string, *int) {
+	currentSession := ""
+	logins := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == userSession && r.Method == http.MethodPost:
+			var login LoginRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&login))
+			if login.Username != "admin@example.com" || login.Password != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			logins++
+			currentSession = []string{"", "session-1", "session-2"}[logins]
+			_ = json.NewEncoder(w).Encode(LoginResponse{ID: currentSession})
+
+		case r.URL.Path == userSession && r.Method == http.MethodDelete:
+			require.Equal(t, currentSession, r.Header.Get(headerSession))
+			currentSession = ""
+			w.WriteHeader(http.StatusNoContent)
+
+		case currentSession == "" || r.Header.Get(headerSession) != currentSession:
+			w.WriteHeader(http.StatusUnauthorized)
+
+		case r.URL.Path == getVersion:
+			_ = json.NewEncoder(w).Encode(VersionInfo{Tag: "v0.56.3"})
+
+		case r.URL.Path == permissionGroups:
+			_ = json.NewEncoder(w).Encode([]*Group{{ID: 1, Name: "All Users"}})
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &currentSession, &logins
+}
+
+func TestSessionAuthentication(t *testing.T) {
+	ctx := context.Background()
+	credentials := Credentials{Username: "admin@example.com", Password: "secret"}
+
+	t.Run("should log in on first use and reuse the session", func(t *testing.T) {
+		server, _, logins := newSessionTestServer(t)
+		c, err := NewV056Client(ctx, server.URL, credentials, false, RetryPolicy{})
+		require.NoError(t, err)
+
+		for range 2 {
+			version, _, err := c.GetVersion(ctx)
+			require.NoError(t, err)
+			require.Equal(t, "v0.56.3", version.Tag)
+		}
+		require.Equal(t, 1, *logins)
+	})
+
+	t.Run("should log in again when the session expired", func(t *testing.T) {
+		server, currentSession, logins := newSessionTestServer(t)
+		c, err := NewV056Client(ctx, server.URL, credentials, false, RetryPolicy{})
+		require.NoError(t, err)
+
+		_, _, err = c.GetVersion(ctx)
+		require.NoError(t, err)
+
+		*currentSession = ""
+
+		groups, _, err := c.ListGroups(ctx)
+		require.NoError(t, err)
+		require.Len(t, groups, 1)
+		require.Equal(t, 2, *logins)
+	})
+
+	t.Run("should end the session on logout", func(t *testing.T) {
+		server, currentSession, _ := newSessionTestServer(t)
+		c, err := NewV056Client(ctx, server.URL, credentials, false, RetryPolicy{})
+		require.NoError(t, err)
+
+		_, _, err = c.GetVersion(ctx)
+		require.NoError(t, err)
+
+		_, err = c.Logout(ctx)
+		require.NoError(t, err)
+		require.Empty(t, *currentSession)
+
+		// Nothing is left to end.
+		_, err = c.Logout(ctx)
+		require.NoError(t, err)
+	})
+
+	t.Run("should return error if the credentials are rejected", func(t *testing.T) {
+		server, _, _ := newSessionTestServer(t)
+		c, err := NewV056Client(ctx, server.URL, Credentials{Username: "admin@example.com", Password: "wrong"}, false, RetryPolicy{})
+		require.NoError(t, err)
+
+		_, _, err = c.GetVersion(ctx)
+		require.ErrorIs(t, err, ErrUnauthorized)
+	})
+
+	t.Run("should require credentials", func(t *testing.T) {
+		_, err := NewV056Client(ctx, "https://metabase.example.com", Credentials{Username: "admin@example.com"}, false, RetryPolicy{})
+		require.Error(t, err)
+	})
+}
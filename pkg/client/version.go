@@ -0,0 +1,81 @@
+package client
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// viewDataGraphMinor is the first release whose data permission graph has the view-data and create-queries
+// dimensions. Earlier releases have a single data dimension instead, see legacyGroupPermission.
+const viewDataGraphMinor = 50
+
+var (
+	// MinimumSupportedVersion is the first release with API keys, which the connector authenticates with.
+	MinimumSupportedVersion = Version{Minor: 49}
+
+	// LatestTestedVersion is the newest release the connector was tested against. Newer releases are
+	// synced with the decoders of this one.
+	LatestTestedVersion = Version{Minor: 56}
+)
+
+// Version is a Metabase release. Open source releases are tagged v0.x.y and paid ones v1.x.y, with the same
+// x.y for the same release, so only Minor and Patch tell releases apart.
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// ParseVersion parses a version tag like "v0.56.3", "v1.56.3.1" or "v0.57.0-RC1".
+func ParseVersion(tag string) (Version, error) {
+	raw := strings.TrimPrefix(strings.TrimSpace(tag), "v")
+	if i := strings.IndexAny(raw, "-+"); i >= 0 {
+		raw = raw[:i]
+	}
+
+	parts := strings.Split(raw, ".")
+	if len(parts) < 2 {
+		return Version{}, fmt.Errorf("unexpected version format: %s", tag)
+	}
+
+	numbers := make([]int, 3)
+	for i := 0; i < len(parts) && i < len(numbers); i++ {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return Version{}, fmt.Errorf("invalid version number %q in tag %s: %w", parts[i], tag, err)
+		}
+		numbers[i] = n
+	}
+
+	return Version{Major: numbers[0], Minor: numbers[1], Patch: numbers[2]}, nil
+}
+
+// Compare returns -1, 0 or 1 when the release of v is older than, the same as or newer than the release of
+// other, regardless of their edition.
+func (v Version) Compare(other Version) int {
+	if v.Minor != other.Minor {
+		return compareInts(v.Minor, other.Minor)
+	}
+	return compareInts(v.Patch, other.Patch)
+}
+
+// SameRelease reports whether v and other are patches of the same release, e.g. v0.56.1 and v1.56.4.
+func (v Version) SameRelease(other Version) bool {
+	return v.Minor == other.Minor
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+func compareInts(a int, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -4,9 +4,24 @@ package config
 import "reflect"
 
 type MetabaseV056 struct {
-	MetabaseBaseUrl      string `mapstructure:"metabase-base-url"`
-	MetabaseApiKey       string `mapstructure:"metabase-api-key"`
-	MetabaseWithPaidPlan bool   `mapstructure:"metabase-with-paid-plan"`
+	MetabaseBaseUrl                              string   `mapstructure:"metabase-base-url"`
+	MetabaseApiKey                               string   `mapstructure:"metabase-api-key"`
+	MetabaseUsername                             string   `mapstructure:"metabase-username"`
+	MetabasePassword                             string   `mapstructure:"metabase-password"`
+	MetabaseWithPaidPlan                         bool     `mapstructure:"metabase-with-paid-plan"`
+	MetabaseDeprovisionRemoveMemberships         bool     `mapstructure:"metabase-deprovision-remove-memberships"`
+	MetabaseDeprovisionArchivePersonalCollection bool     `mapstructure:"metabase-deprovision-archive-personal-collection"`
+	MetabaseDeprovisionTransferCollectionId      string   `mapstructure:"metabase-deprovision-transfer-collection-id"`
+	MetabaseWholePermissionGraph                 bool     `mapstructure:"metabase-whole-permission-graph"`
+	MetabaseWholePermissionGraphMaxDatabases     int      `mapstructure:"metabase-whole-permission-graph-max-databases"`
+	MetabaseIncludeDatabaseNames                 []string `mapstructure:"metabase-include-database-names"`
+	MetabaseExcludeDatabaseNames                 []string `mapstructure:"metabase-exclude-database-names"`
+	MetabaseIncludeDatabaseEngines               []string `mapstructure:"metabase-include-database-engines"`
+	MetabaseExcludeDatabaseEngines               []string `mapstructure:"metabase-exclude-database-engines"`
+	MetabaseIncludeDatabaseIds                   []string `mapstructure:"metabase-include-database-ids"`
+	MetabaseExcludeDatabaseIds                   []string `mapstructure:"metabase-exclude-database-ids"`
+	MetabaseMaxRetries                           int      `mapstructure:"metabase-max-retries"`
+	MetabaseRetryInitialBackoffMs                int      `mapstructure:"metabase-retry-initial-backoff-ms"`
 }
 
 func (c *MetabaseV056) findFieldByTag(tagValue string) (any, bool) {
@@ -14,12 +14,24 @@ var (
 
 	MetabaseApiKey = field.StringField(
 		"metabase-api-key",
-		field.WithRequired(true),
 		field.WithIsSecret(true),
-		field.WithDescription("Metabase API Key"),
+		field.WithDescription("Metabase API Key. Required unless a username and password are set"),
 		field.WithDisplayName("API Key"),
 	)
 
+	MetabaseUsername = field.StringField(
+		"metabase-username",
+		field.WithDescription("Email of the Metabase user to log in as, instead of using an API key"),
+		field.WithDisplayName("Username"),
+	)
+
+	MetabasePassword = field.StringField(
+		"metabase-password",
+		field.WithIsSecret(true),
+		field.WithDescription("Password of the Metabase user to log in as"),
+		field.WithDisplayName("Password"),
+	)
+
 	MetabaseWithPaidPlan = field.BoolField(
 		"metabase-with-paid-plan",
 		field.WithDescription("Set to true if using Metabase paid plan, false for Open Source / self-hosted (free)"),
@@ -27,22 +39,130 @@ var (
 		field.WithDefaultValue(false),
 	)
 
+	MetabaseDeprovisionRemoveMemberships = field.BoolField(
+		"metabase-deprovision-remove-memberships",
+		field.WithDescription("Remove the group memberships of users when deleting them. Deleted users are deactivated, since Metabase cannot delete users"),
+		field.WithDisplayName("Remove group memberships of deleted users"),
+		field.WithDefaultValue(false),
+	)
+
+	MetabaseDeprovisionArchivePersonalCollection = field.BoolField(
+		"metabase-deprovision-archive-personal-collection",
+		field.WithDescription("Archive the content of the personal collection of users when deleting them"),
+		field.WithDisplayName("Archive personal collections of deleted users"),
+		field.WithDefaultValue(false),
+	)
+
+	MetabaseDeprovisionTransferCollectionId = field.StringField(
+		"metabase-deprovision-transfer-collection-id",
+		field.WithDescription("ID of the collection that receives the content of the personal collection of users when deleting them, or root for the root collection"),
+		field.WithDisplayName("Collection for personal collections of deleted users"),
+		field.WithString(func(r *field.StringRuler) { r.Pattern("^(root|[1-9][0-9]*)$") }),
+	)
+
+	MetabaseWholePermissionGraph = field.BoolField(
+		"metabase-whole-permission-graph",
+		field.WithDescription("Fetch the data permissions of every database in a single request per sync instead of one request per database"),
+		field.WithDisplayName("Fetch the whole permission graph"),
+		field.WithDefaultValue(false),
+	)
+
+	MetabaseWholePermissionGraphMaxDatabases = field.IntField(
+		"metabase-whole-permission-graph-max-databases",
+		field.WithDescription("Fetch the data permissions one database at a time when there are more databases than this, even if the whole permission graph is fetched"),
+		field.WithDisplayName("Maximum databases for the whole permission graph"),
+		field.WithDefaultValue(1000),
+	)
+
+	MetabaseIncludeDatabaseNames = field.StringSliceField(
+		"metabase-include-database-names",
+		field.WithDescription("Only sync the databases whose name matches one of these glob patterns, like prod-*"),
+		field.WithDisplayName("Database names to include"),
+	)
+
+	MetabaseExcludeDatabaseNames = field.StringSliceField(
+		"metabase-exclude-database-names",
+		field.WithDescription("Do not sync the databases whose name matches one of these glob patterns, like Sample*"),
+		field.WithDisplayName("Database names to exclude"),
+	)
+
+	MetabaseIncludeDatabaseEngines = field.StringSliceField(
+		"metabase-include-database-engines",
+		field.WithDescription("Only sync the databases with one of these engines, like postgres or bigquery-cloud-sdk"),
+		field.WithDisplayName("Database engines to include"),
+	)
+
+	MetabaseExcludeDatabaseEngines = field.StringSliceField(
+		"metabase-exclude-database-engines",
+		field.WithDescription("Do not sync the databases with one of these engines, like h2"),
+		field.WithDisplayName("Database engines to exclude"),
+	)
+
+	MetabaseIncludeDatabaseIds = field.StringSliceField(
+		"metabase-include-database-ids",
+		field.WithDescription("Only sync the databases with one of these IDs"),
+		field.WithDisplayName("Database IDs to include"),
+	)
+
+	MetabaseExcludeDatabaseIds = field.StringSliceField(
+		"metabase-exclude-database-ids",
+		field.WithDescription("Do not sync the databases with one of these IDs"),
+		field.WithDisplayName("Database IDs to exclude"),
+	)
+
+	MetabaseMaxRetries = field.IntField(
+		"metabase-max-retries",
+		field.WithDescription("Number of times a read is retried when Metabase is unavailable or throttles requests, e.g. while it restarts"),
+		field.WithDisplayName("Maximum retries"),
+		field.WithDefaultValue(3),
+		field.WithInt(func(r *field.IntRuler) { r.Gte(0) }),
+	)
+
+	MetabaseRetryInitialBackoffMs = field.IntField(
+		"metabase-retry-initial-backoff-ms",
+		field.WithDescription("Delay in milliseconds before the first retry of a read, doubled for each following retry, at least 100"),
+		field.WithDisplayName("Initial retry delay (ms)"),
+		field.WithDefaultValue(500),
+		field.WithInt(func(r *field.IntRuler) { r.Gte(0) }),
+	)
+
 	// ConfigurationFields defines the external configuration required for the connector to run.
 	ConfigurationFields = []field.SchemaField{
 		MetabaseBaseUrl,
 		MetabaseApiKey,
+		MetabaseUsername,
+		MetabasePassword,
 		MetabaseWithPaidPlan,
+		MetabaseDeprovisionRemoveMemberships,
+		MetabaseDeprovisionArchivePersonalCollection,
+		MetabaseDeprovisionTransferCollectionId,
+		MetabaseWholePermissionGraph,
+		MetabaseWholePermissionGraphMaxDatabases,
+		MetabaseIncludeDatabaseNames,
+		MetabaseExcludeDatabaseNames,
+		MetabaseIncludeDatabaseEngines,
+		MetabaseExcludeDatabaseEngines,
+		MetabaseIncludeDatabaseIds,
+		MetabaseExcludeDatabaseIds,
+		MetabaseMaxRetries,
+		MetabaseRetryInitialBackoffMs,
 	}
 
 	// FieldRelationships defines relationships between the fields listed in
 	// ConfigurationFields that can be automatically validated. For example, a
 	// username and password can be required together, or an access token can be
 	// marked as mutually exclusive from the username password pair.
-	FieldRelationships = []field.SchemaFieldRelationship{}
+	FieldRelationships = []field.SchemaFieldRelationship{
+		field.FieldsAtLeastOneUsed(MetabaseApiKey, MetabaseUsername),
+		field.FieldsMutuallyExclusive(MetabaseApiKey, MetabaseUsername),
+		field.FieldsRequiredTogether(MetabaseUsername, MetabasePassword),
+		field.FieldsMutuallyExclusive(MetabaseDeprovisionArchivePersonalCollection, MetabaseDeprovisionTransferCollectionId),
+	}
 )
 
 //go:generate go run ./gen
 var Config = field.NewConfiguration(ConfigurationFields,
+	field.WithConstraints(FieldRelationships...),
 	field.WithConnectorDisplayName("Metabase-v056"),
 	field.WithHelpUrl("/docs/baton/metabase"),
 	field.WithIconUrl("/static/app-icons/metabase.svg"),
@@ -21,6 +21,86 @@ func TestValidateConfig(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "valid config - username and password",
+			config: &MetabaseV056{
+				MetabaseUsername: "admin@example.com",
+				MetabasePassword: "some-password",
+				MetabaseBaseUrl:  "https://metabase-example",
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid config - API key and username",
+			config: &MetabaseV056{
+				MetabaseApiKey:   "some-api-key",
+				MetabaseUsername: "admin@example.com",
+				MetabasePassword: "some-password",
+				MetabaseBaseUrl:  "https://metabase-example",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid config - username without password",
+			config: &MetabaseV056{
+				MetabaseUsername: "admin@example.com",
+				MetabaseBaseUrl:  "https://metabase-example",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid config - archive and transfer personal collections",
+			config: &MetabaseV056{
+				MetabaseApiKey:  "some-api-key",
+				MetabaseBaseUrl: "https://metabase-example",
+				MetabaseDeprovisionArchivePersonalCollection: true,
+				MetabaseDeprovisionTransferCollectionId:      "5",
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid config - transfer personal collections to the root collection",
+			config: &MetabaseV056{
+				MetabaseApiKey:                          "some-api-key",
+				MetabaseBaseUrl:                         "https://metabase-example",
+				MetabaseDeprovisionTransferCollectionId: "root",
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid config - invalid transfer collection",
+			config: &MetabaseV056{
+				MetabaseApiKey:                          "some-api-key",
+				MetabaseBaseUrl:                         "https://metabase-example",
+				MetabaseDeprovisionTransferCollectionId: "0",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid config - negative retries",
+			config: &MetabaseV056{
+				MetabaseApiKey:     "some-api-key",
+				MetabaseBaseUrl:    "https://metabase-example",
+				MetabaseMaxRetries: -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid config - negative initial retry backoff",
+			config: &MetabaseV056{
+				MetabaseApiKey:                "some-api-key",
+				MetabaseBaseUrl:               "https://metabase-example",
+				MetabaseRetryInitialBackoffMs: -500,
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid config - no credentials",
+			config: &MetabaseV056{
+				MetabaseBaseUrl: "https://metabase-example",
+			},
+			wantErr: true,
+		},
 		{
 			name: "invalid config - missing required fields",
 			config: &MetabaseV056{
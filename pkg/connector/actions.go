@@ -2,9 +2,15 @@ package connector
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 
+	"github.com/conductorone/baton-metabase-v056/pkg/client"
 	baseConnector "github.com/conductorone/baton-metabase/pkg/connector"
+	config "github.com/conductorone/baton-sdk/pb/c1/config/v1"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/actions"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
 	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
@@ -14,6 +20,265 @@ import (
 	"google.golang.org/protobuf/types/known/structpb"
 )
 
+const (
+	actionRenameGroup = "rename_group"
+	actionUpdateUser  = "update_user"
+
+	actionSetLoginAttributes = "set_login_attributes"
+
+	actionTransferPersonalCollection = "transfer_personal_collection"
+
+	actionSandboxTable   = "sandbox_table"
+	actionUnsandboxTable = "unsandbox_table"
+)
+
+// The modes of the set_login_attributes action.
+const (
+	loginAttributesMerge   = "merge"
+	loginAttributesReplace = "replace"
+)
+
+var renameGroupAction = &v2.BatonActionSchema{
+	Name: actionRenameGroup,
+	Arguments: []*config.Field{
+		{
+			Name:        "groupId",
+			DisplayName: "Group ID",
+			Field:       &config.Field_StringField{},
+			IsRequired:  true,
+		},
+		{
+			Name:        "name",
+			DisplayName: "New Name",
+			Field:       &config.Field_StringField{},
+			IsRequired:  true,
+		},
+	},
+	ReturnTypes: []*config.Field{
+		{
+			Name:        "success",
+			DisplayName: "Success",
+			Field:       &config.Field_BoolField{},
+		},
+	},
+	ActionType: []v2.ActionType{
+		v2.ActionType_ACTION_TYPE_DYNAMIC,
+	},
+}
+
+var updateUserAction = &v2.BatonActionSchema{
+	Name: actionUpdateUser,
+	Arguments: []*config.Field{
+		{
+			Name:        "userId",
+			DisplayName: "User ID",
+			Field:       &config.Field_StringField{},
+			IsRequired:  true,
+		},
+		{
+			Name:        "firstName",
+			DisplayName: "First Name",
+			Field:       &config.Field_StringField{},
+		},
+		{
+			Name:        "lastName",
+			DisplayName: "Last Name",
+			Field:       &config.Field_StringField{},
+		},
+		{
+			Name:        "email",
+			DisplayName: "Email",
+			Field:       &config.Field_StringField{},
+		},
+		{
+			Name:        "locale",
+			DisplayName: "Locale",
+			Description: "Language of the user interface, e.g. en or pt_BR",
+			Field:       &config.Field_StringField{},
+		},
+	},
+	ReturnTypes: []*config.Field{
+		{
+			Name:        "success",
+			DisplayName: "Success",
+			Field:       &config.Field_BoolField{},
+		},
+	},
+	ActionType: []v2.ActionType{
+		v2.ActionType_ACTION_TYPE_ACCOUNT_UPDATE_PROFILE,
+	},
+}
+
+var setLoginAttributesAction = &v2.BatonActionSchema{
+	Name: actionSetLoginAttributes,
+	Arguments: []*config.Field{
+		{
+			Name:        "userId",
+			DisplayName: "User ID",
+			Field:       &config.Field_StringField{},
+			IsRequired:  true,
+		},
+		{
+			Name:        "attributes",
+			DisplayName: "Attributes",
+			Description: "Login attributes to set, e.g. tenant_id. When merging, attributes set to null are removed",
+			Field:       &config.Field_StringMapField{},
+			IsRequired:  true,
+		},
+		{
+			Name:        "mode",
+			DisplayName: "Mode",
+			Description: "Whether to merge the attributes into the current ones (default) or to replace them",
+			Field: &config.Field_StringField{
+				StringField: &config.StringField{
+					DefaultValue: loginAttributesMerge,
+					Options: []*config.StringFieldOption{
+						{Name: loginAttributesMerge, Value: loginAttributesMerge, DisplayName: "Merge"},
+						{Name: loginAttributesReplace, Value: loginAttributesReplace, DisplayName: "Replace"},
+					},
+				},
+			},
+		},
+	},
+	ReturnTypes: []*config.Field{
+		{
+			Name:        "success",
+			DisplayName: "Success",
+			Field:       &config.Field_BoolField{},
+		},
+		{
+			Name:        "attributes",
+			DisplayName: "Attributes",
+			Field:       &config.Field_StringMapField{},
+		},
+	},
+	ActionType: []v2.ActionType{
+		v2.ActionType_ACTION_TYPE_ACCOUNT_UPDATE_PROFILE,
+	},
+}
+
+var transferPersonalCollectionAction = &v2.BatonActionSchema{
+	Name: actionTransferPersonalCollection,
+	Arguments: []*config.Field{
+		{
+			Name:        "userId",
+			DisplayName: "User ID",
+			Field:       &config.Field_StringField{},
+			IsRequired:  true,
+		},
+		{
+			Name:        "targetCollectionId",
+			DisplayName: "Target Collection ID",
+			Description: "ID of the collection that receives the content of the personal collection, or root for the root collection",
+			Field:       &config.Field_StringField{},
+			IsRequired:  true,
+		},
+	},
+	ReturnTypes: []*config.Field{
+		{
+			Name:        "success",
+			DisplayName: "Success",
+			Field:       &config.Field_BoolField{},
+		},
+		{
+			Name:        "moved",
+			DisplayName: "Moved Items",
+			Field:       &config.Field_IntField{},
+		},
+		{
+			Name:        "movedByModel",
+			DisplayName: "Moved Items by Model",
+			Description: "Number of moved items of each model, e.g. dashboard, card or collection",
+			Field:       &config.Field_StringMapField{},
+		},
+	},
+	ActionType: []v2.ActionType{
+		v2.ActionType_ACTION_TYPE_DYNAMIC,
+	},
+}
+
+var sandboxTableAction = &v2.BatonActionSchema{
+	Name: actionSandboxTable,
+	Arguments: []*config.Field{
+		{
+			Name:        "groupId",
+			DisplayName: "Group ID",
+			Field:       &config.Field_StringField{},
+			IsRequired:  true,
+		},
+		{
+			Name:        "tableId",
+			DisplayName: "Table ID",
+			Description: "Metabase ID of the table, the last part of the ID of the table resource",
+			Field:       &config.Field_StringField{},
+			IsRequired:  true,
+		},
+		{
+			Name:        "cardId",
+			DisplayName: "Card ID",
+			Description: "ID of the saved question whose results replace the table for the group",
+			Field:       &config.Field_StringField{},
+		},
+		{
+			Name:        "attributeRemappings",
+			DisplayName: "Attribute Remappings",
+			Description: "Login attributes that filter the rows, mapped to the column or parameter they are matched against, " +
+				"e.g. tenant_id: [\"dimension\", [\"field\", 12, null]]",
+			Field: &config.Field_StringMapField{},
+		},
+	},
+	ReturnTypes: []*config.Field{
+		{
+			Name:        "success",
+			DisplayName: "Success",
+			Field:       &config.Field_BoolField{},
+		},
+		{
+			Name:        "sandboxId",
+			DisplayName: "Sandbox ID",
+			Field:       &config.Field_StringField{},
+		},
+	},
+	ActionType: []v2.ActionType{
+		v2.ActionType_ACTION_TYPE_DYNAMIC,
+	},
+}
+
+var unsandboxTableAction = &v2.BatonActionSchema{
+	Name: actionUnsandboxTable,
+	Arguments: []*config.Field{
+		{
+			Name:        "groupId",
+			DisplayName: "Group ID",
+			Field:       &config.Field_StringField{},
+			IsRequired:  true,
+		},
+		{
+			Name:        "tableId",
+			DisplayName: "Table ID",
+			Description: "Metabase ID of the table, the last part of the ID of the table resource",
+			Field:       &config.Field_StringField{},
+			IsRequired:  true,
+		},
+	},
+	ReturnTypes: []*config.Field{
+		{
+			Name:        "success",
+			DisplayName: "Success",
+			Field:       &config.Field_BoolField{},
+		},
+		{
+			Name:        "changed",
+			DisplayName: "Changed",
+			Description: "Whether the table was sandboxed for the group",
+			Field:       &config.Field_BoolField{},
+		},
+	},
+	ActionType: []v2.ActionType{
+		v2.ActionType_ACTION_TYPE_DYNAMIC,
+	},
+}
+
 func (c *Connector) RegisterActionManager(ctx context.Context) (connectorbuilder.CustomActionManager, error) {
 	actionManager := actions.NewActionManager(ctx)
 
@@ -27,6 +292,38 @@ func (c *Connector) RegisterActionManager(ctx context.Context) (connectorbuilder
 		return nil, err
 	}
 
+	err = actionManager.RegisterAction(ctx, renameGroupAction.Name, renameGroupAction, c.RenameGroup)
+	if err != nil {
+		return nil, err
+	}
+
+	err = actionManager.RegisterAction(ctx, updateUserAction.Name, updateUserAction, c.UpdateUser)
+	if err != nil {
+		return nil, err
+	}
+
+	err = actionManager.RegisterAction(ctx, setLoginAttributesAction.Name, setLoginAttributesAction, c.SetLoginAttributes)
+	if err != nil {
+		return nil, err
+	}
+
+	err = actionManager.RegisterAction(ctx, transferPersonalCollectionAction.Name, transferPersonalCollectionAction, c.TransferPersonalCollection)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.v056Client.IsPaidPlan() {
+		err = actionManager.RegisterAction(ctx, sandboxTableAction.Name, sandboxTableAction, c.SandboxTable)
+		if err != nil {
+			return nil, err
+		}
+
+		err = actionManager.RegisterAction(ctx, unsandboxTableAction.Name, unsandboxTableAction, c.UnsandboxTable)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return actionManager, nil
 }
 
@@ -43,7 +340,7 @@ func (c *Connector) EnableUserV056(ctx context.Context, args *structpb.Struct) (
 		return nil, ann, fmt.Errorf("userId cannot be empty")
 	}
 
-	user, rateLimitDesc, err := c.vBaseClient.GetUserByID(ctx, userId)
+	user, rateLimitDesc, err := c.v056Client.GetUser(ctx, userId)
 	if rateLimitDesc != nil {
 		ann.WithRateLimiting(rateLimitDesc)
 	}
@@ -60,15 +357,19 @@ func (c *Connector) EnableUserV056(ctx context.Context, args *structpb.Struct) (
 		}, ann, nil
 	}
 
-	resp, ann2, err := c.vBaseConnector.EnableUser(ctx, args)
-	if ann2 != nil {
-		ann.Merge(ann2...)
+	_, rateLimitDesc, err = c.v056Client.UpdateUserActiveStatus(ctx, userId, true)
+	if rateLimitDesc != nil {
+		ann.WithRateLimiting(rateLimitDesc)
 	}
 	if err != nil {
 		return nil, ann, fmt.Errorf("failed to enable user %s: %w", userId, err)
 	}
 
-	return resp, ann, nil
+	return &structpb.Struct{
+		Fields: map[string]*structpb.Value{
+			"success": structpb.NewBoolValue(true),
+		},
+	}, ann, nil
 }
 
 func (c *Connector) DisableUserV056(ctx context.Context, args *structpb.Struct) (*structpb.Struct, annotations.Annotations, error) {
@@ -84,7 +385,7 @@ func (c *Connector) DisableUserV056(ctx context.Context, args *structpb.Struct)
 		return nil, ann, fmt.Errorf("userId cannot be empty")
 	}
 
-	user, rateLimitDesc, err := c.vBaseClient.GetUserByID(ctx, userId)
+	user, rateLimitDesc, err := c.v056Client.GetUser(ctx, userId)
 	if rateLimitDesc != nil {
 		ann.WithRateLimiting(rateLimitDesc)
 	}
@@ -101,13 +402,406 @@ func (c *Connector) DisableUserV056(ctx context.Context, args *structpb.Struct)
 		}, ann, nil
 	}
 
-	resp, ann2, err := c.vBaseConnector.DisableUser(ctx, args)
-	if ann2 != nil {
-		ann.Merge(ann2...)
+	_, rateLimitDesc, err = c.v056Client.UpdateUserActiveStatus(ctx, userId, false)
+	if rateLimitDesc != nil {
+		ann.WithRateLimiting(rateLimitDesc)
 	}
 	if err != nil {
 		return nil, ann, fmt.Errorf("failed to disable user %s: %w", userId, err)
 	}
 
-	return resp, ann, nil
+	return &structpb.Struct{
+		Fields: map[string]*structpb.Value{
+			"success": structpb.NewBoolValue(true),
+		},
+	}, ann, nil
+}
+
+// RenameGroup renames a group. The groups that Metabase creates itself cannot be renamed.
+func (c *Connector) RenameGroup(ctx context.Context, args *structpb.Struct) (*structpb.Struct, annotations.Annotations, error) {
+	ann := annotations.New()
+
+	groupID, err := requiredStringArg(args, "groupId")
+	if err != nil {
+		return nil, ann, err
+	}
+
+	name, err := requiredStringArg(args, "name")
+	if err != nil {
+		return nil, ann, err
+	}
+
+	if err := checkGroupEditable(groupID); err != nil {
+		return nil, ann, err
+	}
+
+	_, rateLimitDesc, err := c.v056Client.RenameGroup(ctx, groupID, name)
+	if rateLimitDesc != nil {
+		ann.WithRateLimiting(rateLimitDesc)
+	}
+	if err != nil {
+		return nil, ann, err
+	}
+
+	return &structpb.Struct{
+		Fields: map[string]*structpb.Value{
+			"success": structpb.NewBoolValue(true),
+		},
+	}, ann, nil
+}
+
+// UpdateUser changes the first name, last name, email or locale of a user. Only the arguments that are set are changed.
+func (c *Connector) UpdateUser(ctx context.Context, args *structpb.Struct) (*structpb.Struct, annotations.Annotations, error) {
+	ann := annotations.New()
+
+	userID, err := requiredStringArg(args, "userId")
+	if err != nil {
+		return nil, ann, err
+	}
+
+	update := &client.UserUpdate{
+		FirstName: optionalStringArg(args, "firstName"),
+		LastName:  optionalStringArg(args, "lastName"),
+		Email:     optionalStringArg(args, "email"),
+		Locale:    optionalStringArg(args, "locale"),
+	}
+	if update.FirstName == nil && update.LastName == nil && update.Email == nil && update.Locale == nil {
+		return nil, ann, fmt.Errorf("baton-metabase-v056: at least one of firstName, lastName, email or locale is required")
+	}
+
+	_, rateLimitDesc, err := c.v056Client.UpdateUser(ctx, userID, update)
+	if rateLimitDesc != nil {
+		ann.WithRateLimiting(rateLimitDesc)
+	}
+	if err != nil {
+		return nil, ann, err
+	}
+
+	return &structpb.Struct{
+		Fields: map[string]*structpb.Value{
+			"success": structpb.NewBoolValue(true),
+		},
+	}, ann, nil
+}
+
+// SetLoginAttributes merges login attributes into those of a user, or replaces them. Sandboxes and connection
+// impersonation read these attributes, e.g. tenant_id, to restrict what the user sees.
+func (c *Connector) SetLoginAttributes(ctx context.Context, args *structpb.Struct) (*structpb.Struct, annotations.Annotations, error) {
+	ann := annotations.New()
+
+	userID, err := requiredStringArg(args, "userId")
+	if err != nil {
+		return nil, ann, err
+	}
+
+	attributesField, ok := args.GetFields()["attributes"]
+	if !ok || attributesField.GetStructValue() == nil {
+		return nil, ann, fmt.Errorf("attributes field is required")
+	}
+	attributes := attributesField.GetStructValue().AsMap()
+
+	mode := loginAttributesMerge
+	if m := optionalStringArg(args, "mode"); m != nil {
+		mode = *m
+	}
+
+	switch mode {
+	case loginAttributesReplace:
+	case loginAttributesMerge:
+		user, rateLimitDesc, err := c.v056Client.GetUser(ctx, userID)
+		if rateLimitDesc != nil {
+			ann.WithRateLimiting(rateLimitDesc)
+		}
+		if err != nil {
+			return nil, ann, fmt.Errorf("failed to fetch user %s: %w", userID, err)
+		}
+
+		merged := make(map[string]interface{}, len(user.LoginAttributes)+len(attributes))
+		for name, value := range user.LoginAttributes {
+			merged[name] = value
+		}
+		for name, value := range attributes {
+			if value == nil {
+				delete(merged, name)
+				continue
+			}
+			merged[name] = value
+		}
+		attributes = merged
+	default:
+		return nil, ann, fmt.Errorf("baton-metabase-v056: invalid mode %q, expected %s or %s", mode, loginAttributesMerge, loginAttributesReplace)
+	}
+
+	for name, value := range attributes {
+		if value == nil {
+			delete(attributes, name)
+		}
+	}
+
+	user, rateLimitDesc, err := c.v056Client.UpdateUser(ctx, userID, &client.UserUpdate{LoginAttributes: &attributes})
+	if rateLimitDesc != nil {
+		ann.WithRateLimiting(rateLimitDesc)
+	}
+	if err != nil {
+		return nil, ann, err
+	}
+
+	updated, err := structpb.NewStruct(user.LoginAttributes)
+	if err != nil {
+		return nil, ann, fmt.Errorf("failed to convert login attributes of user %s: %w", userID, err)
+	}
+
+	return &structpb.Struct{
+		Fields: map[string]*structpb.Value{
+			"success":    structpb.NewBoolValue(true),
+			"attributes": structpb.NewStructValue(updated),
+		},
+	}, ann, nil
+}
+
+// TransferPersonalCollection moves the content of the personal collection of a user, like a departing one, into
+// another collection, so that it is not orphaned once the user is disabled.
+func (c *Connector) TransferPersonalCollection(ctx context.Context, args *structpb.Struct) (*structpb.Struct, annotations.Annotations, error) {
+	ann := annotations.New()
+
+	userID, err := requiredStringArg(args, "userId")
+	if err != nil {
+		return nil, ann, err
+	}
+
+	targetArg, err := requiredStringArg(args, "targetCollectionId")
+	if err != nil {
+		return nil, ann, err
+	}
+	targetCollectionID, err := parseTargetCollectionID(targetArg)
+	if err != nil {
+		return nil, ann, err
+	}
+
+	user, rateLimitDesc, err := c.v056Client.GetUser(ctx, userID)
+	if rateLimitDesc != nil {
+		ann.WithRateLimiting(rateLimitDesc)
+	}
+	if err != nil {
+		return nil, ann, fmt.Errorf("failed to fetch user %s: %w", userID, err)
+	}
+
+	if user.PersonalCollectionID == nil {
+		return nil, ann, fmt.Errorf("baton-metabase-v056: user %s has no personal collection", userID)
+	}
+	if strconv.Itoa(*user.PersonalCollectionID) == string(targetCollectionID) {
+		return nil, ann, fmt.Errorf("baton-metabase-v056: collection %s is the personal collection of user %s", targetCollectionID, userID)
+	}
+
+	counts, err := updateCollectionItems(ctx, c.v056Client, *user.PersonalCollectionID, &client.CollectionItemUpdate{
+		CollectionID: targetCollectionID,
+	}, &ann)
+	if err != nil {
+		return nil, ann, fmt.Errorf("failed to transfer personal collection of user %s: %w", userID, err)
+	}
+
+	moved := 0
+	movedByModel := make(map[string]interface{}, len(counts))
+	for model, count := range counts {
+		moved += count
+		movedByModel[model] = count
+	}
+
+	movedByModelStruct, err := structpb.NewStruct(movedByModel)
+	if err != nil {
+		return nil, ann, fmt.Errorf("failed to convert moved items of user %s: %w", userID, err)
+	}
+
+	return &structpb.Struct{
+		Fields: map[string]*structpb.Value{
+			"success":      structpb.NewBoolValue(true),
+			"moved":        structpb.NewNumberValue(float64(moved)),
+			"movedByModel": structpb.NewStructValue(movedByModelStruct),
+		},
+	}, ann, nil
+}
+
+// SandboxTable sandboxes a table for a group on paid plans, filtering its rows with a saved question or with
+// attribute remappings. Revoking the View Data (Sandboxed) grant deletes the sandbox.
+func (c *Connector) SandboxTable(ctx context.Context, args *structpb.Struct) (*structpb.Struct, annotations.Annotations, error) {
+	ann := annotations.New()
+
+	groupArg, err := requiredStringArg(args, "groupId")
+	if err != nil {
+		return nil, ann, err
+	}
+	groupID, err := strconv.Atoi(groupArg)
+	if err != nil {
+		return nil, ann, fmt.Errorf("baton-metabase-v056: invalid group ID %s", groupArg)
+	}
+
+	tableArg, err := requiredStringArg(args, "tableId")
+	if err != nil {
+		return nil, ann, err
+	}
+	tableID, err := strconv.Atoi(tableArg)
+	if err != nil {
+		return nil, ann, fmt.Errorf("baton-metabase-v056: invalid table ID %s", tableArg)
+	}
+
+	sandbox := &client.Sandbox{GroupID: groupID, TableID: tableID}
+
+	if cardArg := optionalStringArg(args, "cardId"); cardArg != nil {
+		cardID, err := strconv.Atoi(*cardArg)
+		if err != nil {
+			return nil, ann, fmt.Errorf("baton-metabase-v056: invalid card ID %s", *cardArg)
+		}
+		sandbox.CardID = &cardID
+	}
+
+	if remappings := args.GetFields()["attributeRemappings"].GetStructValue(); remappings != nil {
+		sandbox.AttributeRemappings = remappings.AsMap()
+	}
+
+	if sandbox.CardID == nil && len(sandbox.AttributeRemappings) == 0 {
+		return nil, ann, fmt.Errorf("baton-metabase-v056: at least one of cardId or attributeRemappings is required")
+	}
+
+	existing, rateLimitDesc, err := findSandbox(ctx, c.v056Client, tableID, groupID)
+	if rateLimitDesc != nil {
+		ann.WithRateLimiting(rateLimitDesc)
+	}
+	if err != nil {
+		return nil, ann, err
+	}
+	if existing != nil {
+		return nil, ann, fmt.Errorf("baton-metabase-v056: group %d already has sandbox %d on table %d", groupID, existing.ID, tableID)
+	}
+
+	// The table is sandboxed in the permission graph of its database, which is keyed by schema.
+	table, rateLimitDesc, err := c.v056Client.GetTable(ctx, tableArg)
+	if rateLimitDesc != nil {
+		ann.WithRateLimiting(rateLimitDesc)
+	}
+	if err != nil {
+		return nil, ann, err
+	}
+
+	created, rateLimitDesc, err := c.v056Client.CreateSandbox(ctx, sandbox)
+	if rateLimitDesc != nil {
+		ann.WithRateLimiting(rateLimitDesc)
+	}
+	if err != nil {
+		return nil, ann, err
+	}
+
+	// The sandbox only filters rows once the group is sandboxed on the table in the graph. It is created first, so
+	// that the table is never sandboxed without a sandbox behind it, and deleted again if the graph update fails.
+	_, err = updateTableViewData(ctx, c.v056Client, strconv.Itoa(table.DBID), table.Schema, tableID, groupID, func(current string) string {
+		if current == sandboxedLevel {
+			return ""
+		}
+		return sandboxedLevel
+	}, &ann)
+	if err != nil {
+		rateLimitDesc, deleteErr := c.v056Client.DeleteSandbox(ctx, strconv.Itoa(created.ID))
+		if rateLimitDesc != nil {
+			ann.WithRateLimiting(rateLimitDesc)
+		}
+		return nil, ann, errors.Join(fmt.Errorf("failed to sandbox table %d for group %d: %w", tableID, groupID, err), deleteErr)
+	}
+
+	return &structpb.Struct{
+		Fields: map[string]*structpb.Value{
+			"success":   structpb.NewBoolValue(true),
+			"sandboxId": structpb.NewStringValue(strconv.Itoa(created.ID)),
+		},
+	}, ann, nil
+}
+
+// UnsandboxTable blocks the view-data access of the group to the table and deletes its sandbox. Access is blocked
+// first, so that the table is never left sandboxed without a sandbox to filter its rows.
+func (c *Connector) UnsandboxTable(ctx context.Context, args *structpb.Struct) (*structpb.Struct, annotations.Annotations, error) {
+	ann := annotations.New()
+
+	groupArg, err := requiredStringArg(args, "groupId")
+	if err != nil {
+		return nil, ann, err
+	}
+	groupID, err := strconv.Atoi(groupArg)
+	if err != nil {
+		return nil, ann, fmt.Errorf("baton-metabase-v056: invalid group ID %s", groupArg)
+	}
+
+	tableArg, err := requiredStringArg(args, "tableId")
+	if err != nil {
+		return nil, ann, err
+	}
+	tableID, err := strconv.Atoi(tableArg)
+	if err != nil {
+		return nil, ann, fmt.Errorf("baton-metabase-v056: invalid table ID %s", tableArg)
+	}
+
+	table, rateLimitDesc, err := c.v056Client.GetTable(ctx, tableArg)
+	if rateLimitDesc != nil {
+		ann.WithRateLimiting(rateLimitDesc)
+	}
+	if err != nil {
+		return nil, ann, err
+	}
+
+	changed, err := updateTableViewData(ctx, c.v056Client, strconv.Itoa(table.DBID), table.Schema, tableID, groupID, func(current string) string {
+		if current != sandboxedLevel {
+			return ""
+		}
+		return viewDataDimension.Revoked
+	}, &ann)
+	if err != nil {
+		return nil, ann, fmt.Errorf("failed to unsandbox table %d for group %d: %w", tableID, groupID, err)
+	}
+
+	sandbox, rateLimitDesc, err := findSandbox(ctx, c.v056Client, tableID, groupID)
+	if rateLimitDesc != nil {
+		ann.WithRateLimiting(rateLimitDesc)
+	}
+	if err != nil {
+		return nil, ann, err
+	}
+
+	if sandbox != nil {
+		rateLimitDesc, err = c.v056Client.DeleteSandbox(ctx, strconv.Itoa(sandbox.ID))
+		if rateLimitDesc != nil {
+			ann.WithRateLimiting(rateLimitDesc)
+		}
+		if err != nil {
+			return nil, ann, err
+		}
+		changed = true
+	}
+
+	return &structpb.Struct{
+		Fields: map[string]*structpb.Value{
+			"success": structpb.NewBoolValue(true),
+			"changed": structpb.NewBoolValue(changed),
+		},
+	}, ann, nil
+}
+
+// requiredStringArg returns the value of a string argument of an action, which must be set and not blank.
+func requiredStringArg(args *structpb.Struct, name string) (string, error) {
+	field, ok := args.GetFields()[name]
+	if !ok || field == nil {
+		return "", fmt.Errorf("%s field is required", name)
+	}
+
+	value := strings.TrimSpace(field.GetStringValue())
+	if value == "" {
+		return "", fmt.Errorf("%s cannot be empty", name)
+	}
+
+	return value, nil
+}
+
+// optionalStringArg returns the value of a string argument of an action, or nil when it is not set or blank.
+func optionalStringArg(args *structpb.Struct, name string) *string {
+	value := strings.TrimSpace(args.GetFields()[name].GetStringValue())
+	if value == "" {
+		return nil
+	}
+	return &value
 }
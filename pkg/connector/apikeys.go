@@ -0,0 +1,195 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/conductorone/baton-metabase-v056/pkg/client"
+	baseConnector "github.com/conductorone/baton-metabase/pkg/connector"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+)
+
+// apiKeyCredentialName names the regenerated key in the plaintext data returned by Rotate.
+const apiKeyCredentialName = "api_key"
+
+// apiKeyBuilder syncs the API keys of the instance. Each key acts as a service user that belongs to one group.
+type apiKeyBuilder struct {
+	client client.ClientService
+}
+
+func (a *apiKeyBuilder) ResourceType(_ context.Context) *v2.ResourceType {
+	return apiKeyResourceType
+}
+
+func (a *apiKeyBuilder) List(ctx context.Context, _ *v2.ResourceId, _ resourceSdk.SyncOpAttrs) ([]*v2.Resource, *resourceSdk.SyncOpResults, error) {
+	ann := annotations.New()
+
+	apiKeys, rateLimitDesc, err := a.client.ListAPIKeys(ctx)
+	if rateLimitDesc != nil {
+		ann.WithRateLimiting(rateLimitDesc)
+	}
+	if err != nil {
+		return nil, &resourceSdk.SyncOpResults{Annotations: ann}, err
+	}
+
+	outResources := make([]*v2.Resource, 0, len(apiKeys))
+	for _, apiKey := range apiKeys {
+		res, err := parseIntoAPIKeyResource(ctx, apiKey)
+		if err != nil {
+			return nil, &resourceSdk.SyncOpResults{Annotations: ann}, err
+		}
+		outResources = append(outResources, res)
+	}
+
+	return outResources, &resourceSdk.SyncOpResults{Annotations: ann}, nil
+}
+
+func (a *apiKeyBuilder) Entitlements(_ context.Context, _ *v2.Resource, _ resourceSdk.SyncOpAttrs) ([]*v2.Entitlement, *resourceSdk.SyncOpResults, error) {
+	return nil, nil, nil
+}
+
+// Grants returns the membership of the API key in its group. The service users of API keys are not listed with
+// the other users, so their memberships are synced here, from the group kept in the profile of the key.
+func (a *apiKeyBuilder) Grants(_ context.Context, resource *v2.Resource, _ resourceSdk.SyncOpAttrs) ([]*v2.Grant, *resourceSdk.SyncOpResults, error) {
+	userTrait, err := resourceSdk.GetUserTrait(resource)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	groupID, ok := resourceSdk.GetProfileInt64Value(userTrait.Profile, "group_id")
+	if !ok {
+		return nil, nil, nil
+	}
+
+	groupResource := &v2.Resource{
+		Id: &v2.ResourceId{
+			ResourceType: baseConnector.GroupResourceType.Id,
+			Resource:     strconv.FormatInt(groupID, 10),
+		},
+	}
+
+	return []*v2.Grant{grant.NewGrant(groupResource, baseConnector.MemberPermission, resource.Id)}, nil, nil
+}
+
+// Rotate regenerates the API key. The previous key stops working immediately.
+func (a *apiKeyBuilder) Rotate(ctx context.Context, resourceId *v2.ResourceId, _ *v2.LocalCredentialOptions) ([]*v2.PlaintextData, annotations.Annotations, error) {
+	if resourceId.ResourceType != apiKeyResourceType.Id {
+		return nil, nil, fmt.Errorf("baton-metabase-v056: only API keys can be rotated, got %s", resourceId.ResourceType)
+	}
+
+	ann := annotations.New()
+
+	regenerated, rateLimitDesc, err := a.client.RegenerateAPIKey(ctx, resourceId.Resource)
+	if rateLimitDesc != nil {
+		ann.WithRateLimiting(rateLimitDesc)
+	}
+	if err != nil {
+		return nil, ann, err
+	}
+
+	if regenerated.UnmaskedKey == "" {
+		return nil, ann, fmt.Errorf("baton-metabase-v056: Metabase returned no key when regenerating API key %s", resourceId.Resource)
+	}
+
+	return []*v2.PlaintextData{
+		{
+			Name:  apiKeyCredentialName,
+			Bytes: []byte(regenerated.UnmaskedKey),
+		},
+	}, ann, nil
+}
+
+// RotateCapabilityDetails reports that Metabase generates the new key itself, so the key is always random.
+func (a *apiKeyBuilder) RotateCapabilityDetails(_ context.Context) (*v2.CredentialDetailsCredentialRotation, annotations.Annotations, error) {
+	return &v2.CredentialDetailsCredentialRotation{
+		SupportedCredentialOptions: []v2.CapabilityDetailCredentialOption{
+			v2.CapabilityDetailCredentialOption_CAPABILITY_DETAIL_CREDENTIAL_OPTION_RANDOM_PASSWORD,
+		},
+		PreferredCredentialOption: v2.CapabilityDetailCredentialOption_CAPABILITY_DETAIL_CREDENTIAL_OPTION_RANDOM_PASSWORD,
+	}, nil, nil
+}
+
+func (a *apiKeyBuilder) Delete(ctx context.Context, resourceId *v2.ResourceId, _ *v2.ResourceId) (annotations.Annotations, error) {
+	if resourceId.ResourceType != apiKeyResourceType.Id {
+		return nil, fmt.Errorf("baton-metabase-v056: only API keys can be deleted, got %s", resourceId.ResourceType)
+	}
+
+	ann := annotations.New()
+
+	rateLimitDesc, err := a.client.DeleteAPIKey(ctx, resourceId.Resource)
+	if rateLimitDesc != nil {
+		ann.WithRateLimiting(rateLimitDesc)
+	}
+	if err != nil {
+		return ann, err
+	}
+
+	return ann, nil
+}
+
+func parseIntoAPIKeyResource(ctx context.Context, apiKey *client.APIKey) (*v2.Resource, error) {
+	profile := map[string]interface{}{
+		"name":       apiKey.Name,
+		"masked_key": apiKey.MaskedKey,
+		"user_id":    apiKey.UserID,
+		"creator_id": apiKey.CreatorID,
+	}
+
+	if apiKey.Group != nil {
+		profile["group_id"] = apiKey.Group.ID
+		profile["group_name"] = apiKey.Group.Name
+	}
+
+	if apiKey.LastUsedAt != "" {
+		profile["last_used_at"] = apiKey.LastUsedAt
+	}
+
+	opts := []resourceSdk.UserTraitOption{
+		resourceSdk.WithAccountType(v2.UserTrait_ACCOUNT_TYPE_SERVICE),
+		resourceSdk.WithStatus(v2.UserTrait_Status_STATUS_ENABLED),
+		resourceSdk.WithUserProfile(profile),
+	}
+
+	if createdAt, ok := parseAPIKeyTimestamp(ctx, "created_at", apiKey.CreatedAt); ok {
+		opts = append(opts, resourceSdk.WithCreatedAt(createdAt))
+	}
+
+	if lastUsedAt, ok := parseAPIKeyTimestamp(ctx, "last_used_at", apiKey.LastUsedAt); ok {
+		opts = append(opts, resourceSdk.WithLastLogin(lastUsedAt))
+	}
+
+	return resourceSdk.NewUserResource(
+		apiKey.Name,
+		apiKeyResourceType,
+		strconv.Itoa(apiKey.ID),
+		opts,
+	)
+}
+
+// parseAPIKeyTimestamp parses a timestamp of the API key, which is empty when the key was never used.
+func parseAPIKeyTimestamp(ctx context.Context, field string, value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		ctxzap.Extract(ctx).Debug("failed to parse API key timestamp", zap.String("field", field), zap.String("value", value), zap.Error(err))
+		return time.Time{}, false
+	}
+
+	return t, true
+}
+
+func newAPIKeyBuilder(client client.ClientService) *apiKeyBuilder {
+	return &apiKeyBuilder{
+		client: client,
+	}
+}
@@ -0,0 +1,165 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/conductorone/baton-metabase-v056/pkg/client"
+	baseConnector "github.com/conductorone/baton-metabase/pkg/connector"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAPIKeyBuilder() (*apiKeyBuilder, *client.MockService) {
+	mockClient := &client.MockService{}
+	builder := newAPIKeyBuilder(mockClient)
+	return builder, mockClient
+}
+
+func TestAPIKeyList(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should list API keys as service users", func(t *testing.T) {
+		builder, mockClient := newTestAPIKeyBuilder()
+		mockClient.ListAPIKeysFunc = func(ctx context.Context) ([]*client.APIKey, *v2.RateLimitDescription, error) {
+			return []*client.APIKey{
+				{
+					ID:         1,
+					UserID:     13,
+					Name:       "Reporting",
+					MaskedKey:  "mb_ABC...",
+					Group:      &client.APIKeyGroup{ID: 3, Name: "Analysts"},
+					CreatorID:  1,
+					CreatedAt:  "2025-06-01T10:00:00Z",
+					LastUsedAt: "2025-07-01T10:00:00Z",
+				},
+				{ID: 2, Name: "Unused", CreatedAt: "2025-06-01T10:00:00Z"},
+			}, nil, nil
+		}
+
+		resources, results, err := builder.List(ctx, nil, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.NoError(t, err)
+		nextPageToken := results.NextPageToken
+		require.Empty(t, nextPageToken)
+		require.Len(t, resources, 2)
+		require.Equal(t, "1", resources[0].Id.Resource)
+		require.Equal(t, apiKeyResourceType.Id, resources[0].Id.ResourceType)
+
+		userTrait, err := resourceSdk.GetUserTrait(resources[0])
+		require.NoError(t, err)
+		require.Equal(t, v2.UserTrait_ACCOUNT_TYPE_SERVICE, userTrait.AccountType)
+		require.NotNil(t, userTrait.LastLogin)
+		require.Equal(t, "Analysts", userTrait.Profile.Fields["group_name"].GetStringValue())
+
+		unusedTrait, err := resourceSdk.GetUserTrait(resources[1])
+		require.NoError(t, err)
+		require.Nil(t, unusedTrait.LastLogin)
+	})
+
+	t.Run("should return error if ListAPIKeys fails", func(t *testing.T) {
+		builder, mockClient := newTestAPIKeyBuilder()
+		mockClient.ListAPIKeysFunc = func(ctx context.Context) ([]*client.APIKey, *v2.RateLimitDescription, error) {
+			return nil, nil, fmt.Errorf("API error")
+		}
+
+		_, _, err := builder.List(ctx, nil, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.Error(t, err)
+	})
+}
+
+func TestAPIKeyGrants(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should grant the membership of the group of the key", func(t *testing.T) {
+		builder, _ := newTestAPIKeyBuilder()
+		resource, err := parseIntoAPIKeyResource(ctx, &client.APIKey{
+			ID:     1,
+			UserID: 13,
+			Name:   "Reporting",
+			Group:  &client.APIKeyGroup{ID: 3, Name: "Analysts"},
+		})
+		require.NoError(t, err)
+
+		grants, _, err := builder.Grants(ctx, resource, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.NoError(t, err)
+		require.Len(t, grants, 1)
+		require.Equal(t, "group:3:member", grants[0].Entitlement.Id)
+		require.Equal(t, baseConnector.GroupResourceType.Id, grants[0].Entitlement.Resource.Id.ResourceType)
+		require.Equal(t, resource.Id, grants[0].Principal.Id)
+	})
+
+	t.Run("should grant nothing to a key without a group", func(t *testing.T) {
+		builder, _ := newTestAPIKeyBuilder()
+		resource, err := parseIntoAPIKeyResource(ctx, &client.APIKey{ID: 2, Name: "Unused"})
+		require.NoError(t, err)
+
+		grants, _, err := builder.Grants(ctx, resource, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.NoError(t, err)
+		require.Empty(t, grants)
+	})
+}
+
+func TestAPIKeyRotate(t *testing.T) {
+	ctx := context.Background()
+	apiKeyID := &v2.ResourceId{ResourceType: apiKeyResourceType.Id, Resource: "1"}
+
+	t.Run("should return the regenerated key", func(t *testing.T) {
+		builder, mockClient := newTestAPIKeyBuilder()
+		mockClient.RegenerateAPIKeyFunc = func(ctx context.Context, id string) (*client.RegeneratedAPIKey, *v2.RateLimitDescription, error) {
+			require.Equal(t, "1", id)
+			return &client.RegeneratedAPIKey{ID: 1, UnmaskedKey: "mb_new"}, nil, nil
+		}
+
+		data, _, err := builder.Rotate(ctx, apiKeyID, nil)
+		require.NoError(t, err)
+		require.Len(t, data, 1)
+		require.Equal(t, apiKeyCredentialName, data[0].Name)
+		require.Equal(t, []byte("mb_new"), data[0].Bytes)
+	})
+
+	t.Run("should return error if no key is returned", func(t *testing.T) {
+		builder, mockClient := newTestAPIKeyBuilder()
+		mockClient.RegenerateAPIKeyFunc = func(ctx context.Context, id string) (*client.RegeneratedAPIKey, *v2.RateLimitDescription, error) {
+			return &client.RegeneratedAPIKey{ID: 1}, nil, nil
+		}
+
+		_, _, err := builder.Rotate(ctx, apiKeyID, nil)
+		require.Error(t, err)
+	})
+
+	t.Run("should reject other resource types", func(t *testing.T) {
+		builder, _ := newTestAPIKeyBuilder()
+
+		_, _, err := builder.Rotate(ctx, &v2.ResourceId{ResourceType: "user", Resource: "1"}, nil)
+		require.Error(t, err)
+	})
+}
+
+func TestAPIKeyDelete(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should delete the API key", func(t *testing.T) {
+		builder, mockClient := newTestAPIKeyBuilder()
+		var deleted string
+		mockClient.DeleteAPIKeyFunc = func(ctx context.Context, id string) (*v2.RateLimitDescription, error) {
+			deleted = id
+			return nil, nil
+		}
+
+		_, err := builder.Delete(ctx, &v2.ResourceId{ResourceType: apiKeyResourceType.Id, Resource: "7"}, nil)
+		require.NoError(t, err)
+		require.Equal(t, "7", deleted)
+	})
+
+	t.Run("should return error if DeleteAPIKey fails", func(t *testing.T) {
+		builder, mockClient := newTestAPIKeyBuilder()
+		mockClient.DeleteAPIKeyFunc = func(ctx context.Context, id string) (*v2.RateLimitDescription, error) {
+			return nil, fmt.Errorf("API error")
+		}
+
+		_, err := builder.Delete(ctx, &v2.ResourceId{ResourceType: apiKeyResourceType.Id, Resource: "7"}, nil)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,188 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/conductorone/baton-metabase-v056/pkg/client"
+	baseConnector "github.com/conductorone/baton-metabase/pkg/connector"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+const (
+	applicationID          = "application"
+	applicationDisplayName = "Application Permissions"
+
+	// Values of the application permission graph.
+	applicationAccessYes = "yes"
+	applicationAccessNo  = "no"
+)
+
+// applicationPermissions are the keys of the application permission graph, which is only available on paid plans.
+var applicationPermissions = []struct {
+	ID          string
+	DisplayName string
+	Description string
+}{
+	{ID: "setting", DisplayName: "Settings Access", Description: "Grants access to the admin settings, except authentication and license settings"},
+	{ID: "monitoring", DisplayName: "Monitoring Access", Description: "Grants access to tools, audit and troubleshooting"},
+	{ID: "subscription", DisplayName: "Subscriptions and Alerts", Description: "Grants creating dashboard subscriptions and alerts"},
+}
+
+type applicationBuilder struct {
+	client client.ClientService
+}
+
+func (a *applicationBuilder) ResourceType(_ context.Context) *v2.ResourceType {
+	return applicationResourceType
+}
+
+func (a *applicationBuilder) List(_ context.Context, _ *v2.ResourceId, _ resourceSdk.SyncOpAttrs) ([]*v2.Resource, *resourceSdk.SyncOpResults, error) {
+	res, err := resourceSdk.NewResource(applicationDisplayName, applicationResourceType, applicationID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return []*v2.Resource{res}, nil, nil
+}
+
+func (a *applicationBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ resourceSdk.SyncOpAttrs) ([]*v2.Entitlement, *resourceSdk.SyncOpResults, error) {
+	rv := make([]*v2.Entitlement, 0, len(applicationPermissions))
+	for _, permission := range applicationPermissions {
+		rv = append(rv, entitlement.NewPermissionEntitlement(resource, permission.ID,
+			entitlement.WithGrantableTo(baseConnector.GroupResourceType),
+			entitlement.WithDisplayName(permission.DisplayName),
+			entitlement.WithDescription(permission.Description),
+		))
+	}
+
+	return rv, nil, nil
+}
+
+func (a *applicationBuilder) Grants(ctx context.Context, resource *v2.Resource, _ resourceSdk.SyncOpAttrs) ([]*v2.Grant, *resourceSdk.SyncOpResults, error) {
+	ann := annotations.New()
+
+	graph, rateLimitDesc, err := a.client.GetApplicationPermissions(ctx)
+	if rateLimitDesc != nil {
+		ann.WithRateLimiting(rateLimitDesc)
+	}
+	if err != nil {
+		return nil, &resourceSdk.SyncOpResults{Annotations: ann}, err
+	}
+
+	var grants []*v2.Grant
+	for groupID, groupPermissions := range graph.Groups {
+		for _, permission := range applicationPermissions {
+			if groupPermissions[permission.ID] != applicationAccessYes {
+				continue
+			}
+			grants = append(grants, newGroupGrant(resource, permission.ID, groupID, a.client.IsPaidPlan()))
+		}
+	}
+
+	return grants, &resourceSdk.SyncOpResults{Annotations: ann}, nil
+}
+
+func (a *applicationBuilder) Grant(ctx context.Context, principal *v2.Resource, ent *v2.Entitlement) (annotations.Annotations, error) {
+	if principal.Id.ResourceType != baseConnector.GroupResourceType.Id {
+		return nil, fmt.Errorf("baton-metabase-v056: only groups can be granted application permissions, got %s", principal.Id.ResourceType)
+	}
+
+	permission, err := findApplicationPermission(permissionFromEntitlement(ent))
+	if err != nil {
+		return nil, err
+	}
+
+	groupID := principal.Id.Resource
+
+	ann, changed, err := a.setGroupApplicationAccess(ctx, groupID, permission, applicationAccessYes)
+	if err != nil {
+		return ann, fmt.Errorf("failed to grant %s application permission to group %s: %w", permission, groupID, err)
+	}
+
+	if !changed {
+		ann.Append(&v2.GrantAlreadyExists{})
+	}
+
+	return ann, nil
+}
+
+func (a *applicationBuilder) Revoke(ctx context.Context, g *v2.Grant) (annotations.Annotations, error) {
+	principal := g.Principal
+	if principal.Id.ResourceType != baseConnector.GroupResourceType.Id {
+		return nil, fmt.Errorf("baton-metabase-v056: only groups can be revoked application permissions, got %s", principal.Id.ResourceType)
+	}
+
+	permission, err := findApplicationPermission(permissionFromEntitlement(g.Entitlement))
+	if err != nil {
+		return nil, err
+	}
+
+	groupID := principal.Id.Resource
+
+	ann, changed, err := a.setGroupApplicationAccess(ctx, groupID, permission, applicationAccessNo)
+	if err != nil {
+		return ann, fmt.Errorf("failed to revoke %s application permission from group %s: %w", permission, groupID, err)
+	}
+
+	if !changed {
+		ann.Append(&v2.GrantAlreadyRevoked{})
+	}
+
+	return ann, nil
+}
+
+// setGroupApplicationAccess writes the access of the group to the application permission, unless the group
+// already has it. It returns whether the graph was updated.
+func (a *applicationBuilder) setGroupApplicationAccess(ctx context.Context, groupID string, permission string, access string) (annotations.Annotations, bool, error) {
+	ann := annotations.New()
+
+	changed, err := updateGraphWithRetry(ctx, func() (bool, error) {
+		graph, rateLimitDesc, err := a.client.GetApplicationPermissions(ctx)
+		if rateLimitDesc != nil {
+			ann.WithRateLimiting(rateLimitDesc)
+		}
+		if err != nil {
+			return false, err
+		}
+
+		current := graph.Groups[groupID][permission]
+		if current == access || (current == "" && access == applicationAccessNo) {
+			return false, nil
+		}
+
+		rateLimitDesc, err = a.client.UpdateApplicationPermissions(ctx, &client.ApplicationPermissionGraph{
+			Revision: graph.Revision,
+			Groups: map[string]map[string]string{
+				groupID: {permission: access},
+			},
+		})
+		if rateLimitDesc != nil {
+			ann.WithRateLimiting(rateLimitDesc)
+		}
+		if err != nil {
+			return false, err
+		}
+		return true, nil
+	})
+
+	return ann, changed, err
+}
+
+func findApplicationPermission(id string) (string, error) {
+	for _, permission := range applicationPermissions {
+		if permission.ID == id {
+			return permission.ID, nil
+		}
+	}
+	return "", fmt.Errorf("baton-metabase-v056: unknown application permission %s", id)
+}
+
+func newApplicationBuilder(client client.ClientService) *applicationBuilder {
+	return &applicationBuilder{
+		client: client,
+	}
+}
@@ -0,0 +1,119 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/conductorone/baton-metabase-v056/pkg/client"
+	baseConnector "github.com/conductorone/baton-metabase/pkg/connector"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestApplicationBuilder() (*applicationBuilder, *client.MockService) {
+	mockClient := &client.MockService{IsPaidPlanFunc: func() bool { return true }}
+	builder := newApplicationBuilder(mockClient)
+	return builder, mockClient
+}
+
+func TestApplicationGrants(t *testing.T) {
+	ctx := context.Background()
+	applicationResource := &v2.Resource{
+		Id:          &v2.ResourceId{ResourceType: applicationResourceType.Id, Resource: applicationID},
+		DisplayName: applicationDisplayName,
+	}
+
+	t.Run("should grant each permission set to yes", func(t *testing.T) {
+		builder, mockClient := newTestApplicationBuilder()
+		mockClient.GetApplicationPermissionsFunc = func(ctx context.Context) (*client.ApplicationPermissionGraph, *v2.RateLimitDescription, error) {
+			return &client.ApplicationPermissionGraph{Revision: 2, Groups: map[string]map[string]string{
+				"1": {"setting": "no", "monitoring": "no", "subscription": "yes"},
+				"3": {"setting": "yes", "monitoring": "yes", "subscription": "no"},
+			}}, nil, nil
+		}
+
+		grants, _, err := builder.Grants(ctx, applicationResource, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.NoError(t, err)
+
+		granted := grantedPermissions(grants)
+		require.ElementsMatch(t, []string{"subscription"}, granted["1"])
+		require.ElementsMatch(t, []string{"setting", "monitoring"}, granted["3"])
+	})
+
+	t.Run("should return error if GetApplicationPermissions fails", func(t *testing.T) {
+		builder, mockClient := newTestApplicationBuilder()
+		mockClient.GetApplicationPermissionsFunc = func(ctx context.Context) (*client.ApplicationPermissionGraph, *v2.RateLimitDescription, error) {
+			return nil, nil, fmt.Errorf("API error")
+		}
+
+		_, _, err := builder.Grants(ctx, applicationResource, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.Error(t, err)
+	})
+}
+
+func TestApplicationGrantAndRevoke(t *testing.T) {
+	ctx := context.Background()
+	groupResource := &v2.Resource{Id: &v2.ResourceId{ResourceType: baseConnector.GroupResourceType.Id, Resource: "3"}}
+	settingEntitlement := &v2.Entitlement{Id: fmt.Sprintf("%s:%s:setting", applicationResourceType.Id, applicationID)}
+
+	graph := func(setting string) func(ctx context.Context) (*client.ApplicationPermissionGraph, *v2.RateLimitDescription, error) {
+		return func(ctx context.Context) (*client.ApplicationPermissionGraph, *v2.RateLimitDescription, error) {
+			return &client.ApplicationPermissionGraph{Revision: 2, Groups: map[string]map[string]string{
+				"3": {"setting": setting},
+			}}, nil, nil
+		}
+	}
+
+	t.Run("should set the permission to yes on grant", func(t *testing.T) {
+		builder, mockClient := newTestApplicationBuilder()
+		mockClient.GetApplicationPermissionsFunc = graph("no")
+
+		var updated *client.ApplicationPermissionGraph
+		mockClient.UpdateApplicationPermissionsFunc = func(ctx context.Context, g *client.ApplicationPermissionGraph) (*v2.RateLimitDescription, error) {
+			updated = g
+			return nil, nil
+		}
+
+		ann, err := builder.Grant(ctx, groupResource, settingEntitlement)
+		require.NoError(t, err)
+		require.False(t, ann.Contains(&v2.GrantAlreadyExists{}))
+		require.Equal(t, &client.ApplicationPermissionGraph{Revision: 2, Groups: map[string]map[string]string{
+			"3": {"setting": "yes"},
+		}}, updated)
+	})
+
+	t.Run("should return GrantAlreadyExists if the group has the permission", func(t *testing.T) {
+		builder, mockClient := newTestApplicationBuilder()
+		mockClient.GetApplicationPermissionsFunc = graph("yes")
+
+		ann, err := builder.Grant(ctx, groupResource, settingEntitlement)
+		require.NoError(t, err)
+		require.True(t, ann.Contains(&v2.GrantAlreadyExists{}))
+	})
+
+	t.Run("should set the permission to no on revoke", func(t *testing.T) {
+		builder, mockClient := newTestApplicationBuilder()
+		mockClient.GetApplicationPermissionsFunc = graph("yes")
+
+		var updated *client.ApplicationPermissionGraph
+		mockClient.UpdateApplicationPermissionsFunc = func(ctx context.Context, g *client.ApplicationPermissionGraph) (*v2.RateLimitDescription, error) {
+			updated = g
+			return nil, nil
+		}
+
+		_, err := builder.Revoke(ctx, &v2.Grant{Entitlement: settingEntitlement, Principal: groupResource})
+		require.NoError(t, err)
+		require.Equal(t, "no", updated.Groups["3"]["setting"])
+	})
+
+	t.Run("should return GrantAlreadyRevoked if the group does not have the permission", func(t *testing.T) {
+		builder, mockClient := newTestApplicationBuilder()
+		mockClient.GetApplicationPermissionsFunc = graph("no")
+
+		ann, err := builder.Revoke(ctx, &v2.Grant{Entitlement: settingEntitlement, Principal: groupResource})
+		require.NoError(t, err)
+		require.True(t, ann.Contains(&v2.GrantAlreadyRevoked{}))
+	})
+}
@@ -0,0 +1,99 @@
+package connector
+
+import (
+	"sync"
+)
+
+// syncCache keeps what the builders read from Metabase during a sync, so that builders reading the same data, like
+// the group memberships, fetch it once per sync. Values are kept for the sync whose ID they were read for, and are
+// dropped as soon as a value is kept for another sync. Nothing is kept for calls made without a sync ID.
+type syncCache struct {
+	mu     sync.Mutex
+	syncID string
+	values map[string]any
+	// fetching holds a lock per key, so that builders syncing concurrently wait for a single fetch of a value.
+	fetching map[string]*sync.Mutex
+}
+
+func newSyncCache() *syncCache {
+	return &syncCache{}
+}
+
+// fetchLock returns the lock that fetches of the key take during the sync.
+func (c *syncCache) fetchLock(syncID string, key string) *sync.Mutex {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.scope(syncID)
+	if c.fetching[key] == nil {
+		c.fetching[key] = &sync.Mutex{}
+	}
+	return c.fetching[key]
+}
+
+// scope drops the values of the previous sync when syncID is another one. Callers hold mu.
+func (c *syncCache) scope(syncID string) {
+	if c.values != nil && c.syncID == syncID {
+		return
+	}
+
+	c.syncID = syncID
+	c.values = make(map[string]any)
+	c.fetching = make(map[string]*sync.Mutex)
+}
+
+// getCached returns the value kept under the key for the sync.
+func getCached[V any](c *syncCache, syncID string, key string) (V, bool) {
+	var zero V
+	if syncID == "" {
+		return zero, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.syncID != syncID {
+		return zero, false
+	}
+	value, ok := c.values[key].(V)
+	return value, ok
+}
+
+// putCached keeps the value under the key for the rest of the sync.
+func putCached[V any](c *syncCache, syncID string, key string, value V) {
+	if syncID == "" {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.scope(syncID)
+	c.values[key] = value
+}
+
+// fetchCached returns the value kept under the key for the sync, or reads it and keeps it for the rest of the sync.
+func fetchCached[V any](c *syncCache, syncID string, key string, read func() (V, error)) (V, error) {
+	if value, ok := getCached[V](c, syncID, key); ok {
+		return value, nil
+	}
+	if syncID == "" {
+		return read()
+	}
+
+	fetchMu := c.fetchLock(syncID, key)
+	fetchMu.Lock()
+	defer fetchMu.Unlock()
+
+	// Another builder may have read the value in the meantime.
+	if value, ok := getCached[V](c, syncID, key); ok {
+		return value, nil
+	}
+
+	value, err := read()
+	if err != nil {
+		return value, err
+	}
+	putCached(c, syncID, key, value)
+	return value, nil
+}
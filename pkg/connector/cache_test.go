@@ -0,0 +1,79 @@
+package connector
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncCache(t *testing.T) {
+	t.Run("should keep values for the sync they were read for", func(t *testing.T) {
+		cache := newSyncCache()
+		putCached(cache, "sync-1", "key", 1)
+
+		value, ok := getCached[int](cache, "sync-1", "key")
+		require.True(t, ok)
+		require.Equal(t, 1, value)
+
+		_, ok = getCached[int](cache, "sync-2", "key")
+		require.False(t, ok)
+	})
+
+	t.Run("should drop the values of the previous sync", func(t *testing.T) {
+		cache := newSyncCache()
+		putCached(cache, "sync-1", "key", 1)
+		putCached(cache, "sync-2", "other", 2)
+
+		_, ok := getCached[int](cache, "sync-1", "key")
+		require.False(t, ok)
+		_, ok = getCached[int](cache, "sync-2", "key")
+		require.False(t, ok)
+	})
+
+	t.Run("should keep nothing without a sync ID", func(t *testing.T) {
+		cache := newSyncCache()
+		putCached(cache, "", "key", 1)
+
+		_, ok := getCached[int](cache, "", "key")
+		require.False(t, ok)
+	})
+
+	t.Run("should fetch a value once for concurrent callers", func(t *testing.T) {
+		cache := newSyncCache()
+		var mu sync.Mutex
+		reads := 0
+
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				value, err := fetchCached(cache, "sync-1", "key", func() (string, error) {
+					mu.Lock()
+					defer mu.Unlock()
+					reads++
+					return "value", nil
+				})
+				require.NoError(t, err)
+				require.Equal(t, "value", value)
+			}()
+		}
+		wg.Wait()
+
+		require.Equal(t, 1, reads)
+	})
+
+	t.Run("should not keep a value that failed to be read", func(t *testing.T) {
+		cache := newSyncCache()
+
+		_, err := fetchCached(cache, "sync-1", "key", func() (int, error) {
+			return 0, fmt.Errorf("API error")
+		})
+		require.Error(t, err)
+
+		_, ok := getCached[int](cache, "sync-1", "key")
+		require.False(t, ok)
+	})
+}
@@ -0,0 +1,103 @@
+package connector
+
+import (
+	"context"
+
+	"github.com/conductorone/baton-metabase-v056/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+// cardBuilder syncs the cards of the instance: saved questions, models and metrics.
+type cardBuilder struct {
+	client client.ClientService
+	cache  *syncCache
+}
+
+func (c *cardBuilder) ResourceType(_ context.Context) *v2.ResourceType {
+	return cardResourceType
+}
+
+// List returns every card at once, archived ones included, each with its collection as parent.
+func (c *cardBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, attrs resourceSdk.SyncOpAttrs) ([]*v2.Resource, *resourceSdk.SyncOpResults, error) {
+	if parentResourceID != nil {
+		return nil, nil, nil
+	}
+
+	ann := annotations.New()
+
+	collectionIDs, err := fetchCollectionIDs(ctx, c.cache, c.client, attrs.SyncID, &ann)
+	if err != nil {
+		return nil, &resourceSdk.SyncOpResults{Annotations: ann}, err
+	}
+
+	var outResources []*v2.Resource
+	for _, archived := range []bool{false, true} {
+		cards, rateLimitDesc, err := c.client.ListCards(ctx, archived)
+		if rateLimitDesc != nil {
+			ann.WithRateLimiting(rateLimitDesc)
+		}
+		if err != nil {
+			return nil, &resourceSdk.SyncOpResults{Annotations: ann}, err
+		}
+
+		for _, card := range cards {
+			res, err := parseIntoContentResource(ctx, cardContentItem(card), cardResourceType, collectionIDs, cardProfile(card))
+			if err != nil {
+				return nil, &resourceSdk.SyncOpResults{Annotations: ann}, err
+			}
+			if res != nil {
+				outResources = append(outResources, res)
+			}
+		}
+	}
+
+	return outResources, &resourceSdk.SyncOpResults{Annotations: ann}, nil
+}
+
+func (c *cardBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ resourceSdk.SyncOpAttrs) ([]*v2.Entitlement, *resourceSdk.SyncOpResults, error) {
+	return creatorEntitlements(resource, "card"), nil, nil
+}
+
+func (c *cardBuilder) Grants(_ context.Context, resource *v2.Resource, _ resourceSdk.SyncOpAttrs) ([]*v2.Grant, *resourceSdk.SyncOpResults, error) {
+	grants, err := creatorGrants(resource)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return grants, nil, nil
+}
+
+func cardContentItem(card *client.Card) *contentItem {
+	return &contentItem{
+		ID:              card.ID,
+		Name:            card.Name,
+		Description:     card.Description,
+		CollectionID:    card.CollectionID,
+		CreatorID:       card.CreatorID,
+		Archived:        card.Archived,
+		PublicUUID:      card.PublicUUID,
+		EnableEmbedding: card.EnableEmbedding,
+		CreatedAt:       card.CreatedAt,
+		UpdatedAt:       card.UpdatedAt,
+	}
+}
+
+// cardProfile returns the profile fields that only cards have.
+func cardProfile(card *client.Card) map[string]interface{} {
+	profile := map[string]interface{}{
+		"type": card.Type,
+	}
+	if card.DatabaseID != nil {
+		profile["database_id"] = *card.DatabaseID
+	}
+	return profile
+}
+
+func newCardBuilder(client client.ClientService, cache *syncCache) *cardBuilder {
+	return &cardBuilder{
+		client: client,
+		cache:  cache,
+	}
+}
@@ -0,0 +1,53 @@
+package connector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/conductorone/baton-metabase-v056/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCardBuilder() (*cardBuilder, *client.MockService) {
+	mockClient := &client.MockService{}
+	builder := newCardBuilder(mockClient, newSyncCache())
+	return builder, mockClient
+}
+
+func TestCardsList(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should list cards with their type and database", func(t *testing.T) {
+		builder, mockClient := newTestCardBuilder()
+		salesID := 1
+		databaseID := 2
+		mockClient.ListCollectionsFunc = testContentCollections
+		mockClient.ListCardsFunc = func(ctx context.Context, archived bool) ([]*client.Card, *v2.RateLimitDescription, error) {
+			if archived {
+				return nil, nil, nil
+			}
+			return []*client.Card{
+				{ID: 12, Name: "Revenue by month", Type: "question", CollectionID: &salesID, DatabaseID: &databaseID, CreatorID: 4, EnableEmbedding: true},
+			}, nil, nil
+		}
+
+		resources, _, err := builder.List(ctx, nil, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.NoError(t, err)
+		require.Len(t, resources, 1)
+		require.Equal(t, "1", resources[0].GetParentResourceId().GetResource())
+
+		appTrait, err := resourceSdk.GetAppTrait(resources[0])
+		require.NoError(t, err)
+		require.Equal(t, "question", appTrait.Profile.Fields["type"].GetStringValue())
+		require.Equal(t, float64(2), appTrait.Profile.Fields["database_id"].GetNumberValue())
+		require.True(t, appTrait.Profile.Fields["enable_embedding"].GetBoolValue())
+		require.False(t, appTrait.Profile.Fields["public_link"].GetBoolValue())
+
+		grants, _, err := builder.Grants(ctx, resources[0], resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.NoError(t, err)
+		require.Len(t, grants, 1)
+		require.Equal(t, "4", grants[0].Principal.Id.Resource)
+	})
+}
@@ -0,0 +1,418 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/conductorone/baton-metabase-v056/pkg/client"
+	baseConnector "github.com/conductorone/baton-metabase/pkg/connector"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+const (
+	collectionReadPermission   = "read"
+	collectionCuratePermission = "curate"
+	// collectionOwnerPermission links a personal collection to its owner, who is the only one to access it.
+	collectionOwnerPermission = "owner"
+
+	// Values of the collection permission graph.
+	collectionReadAccess  = "read"
+	collectionWriteAccess = "write"
+	collectionNoAccess    = "none"
+
+	rootCollectionDisplayName = "Our analytics"
+)
+
+// collectionPermissions maps each collection entitlement to the access it stands for in the collection graph.
+var collectionPermissions = []struct {
+	ID          string
+	DisplayName string
+	Access      string
+}{
+	{ID: collectionReadPermission, DisplayName: "Read", Access: collectionReadAccess},
+	{ID: collectionCuratePermission, DisplayName: "Curate", Access: collectionWriteAccess},
+}
+
+// collectionAccessRanks orders the accesses of the collection graph: write access includes read access, so groups
+// with it are granted both the curate and the read entitlements.
+var collectionAccessRanks = map[string]int{
+	collectionReadAccess:  0,
+	collectionWriteAccess: 1,
+}
+
+// collectionAccessIncludes reports whether the current access of a group gives access, either because it is that
+// access or because it outranks it.
+func collectionAccessIncludes(current string, access string) bool {
+	if current == access {
+		return true
+	}
+	rank, ok := collectionAccessRanks[current]
+	accessRank, accessOk := collectionAccessRanks[access]
+	return ok && accessOk && rank > accessRank
+}
+
+type collectionBuilder struct {
+	client client.ClientService
+	cache  *syncCache
+}
+
+// Keys of what collections share in the sync cache. The collection permission graph is kept so that the grants of
+// every collection come from a single fetch of the whole graph rather than one fetch per collection. The IDs of the
+// synced collections are kept for the dashboard and card builders, to place their content without listing the
+// collections again.
+const (
+	collectionGraphCacheKey = "collection-graph"
+	collectionIDsCacheKey   = "collection-ids"
+)
+
+// collectionIDSet returns the IDs of the collections, to which the root collection is added.
+func collectionIDSet(collections []*client.Collection) map[string]bool {
+	ids := map[string]bool{string(client.RootCollectionID): true}
+	for _, collection := range collections {
+		ids[string(collection.ID)] = true
+	}
+	return ids
+}
+
+// fetchCollectionIDs returns the IDs of the synced collections, which are the possible parents of dashboards and
+// cards. They are kept by the collection builder when it lists the collections, and only listed here when it has
+// not yet.
+func fetchCollectionIDs(ctx context.Context, cache *syncCache, cl client.ClientService, syncID string, ann *annotations.Annotations) (map[string]bool, error) {
+	return fetchCached(cache, syncID, collectionIDsCacheKey, func() (map[string]bool, error) {
+		collections, rateLimitDesc, err := cl.ListCollections(ctx)
+		if rateLimitDesc != nil {
+			ann.WithRateLimiting(rateLimitDesc)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return collectionIDSet(collections), nil
+	})
+}
+
+func (c *collectionBuilder) ResourceType(_ context.Context) *v2.ResourceType {
+	return collectionResourceType
+}
+
+// List returns every collection at once, each with its parent collection, so that the collection tree
+// is synced with a single request. The personal collections of every user are synced along with their
+// subcollections, which are linked to the owner of the personal collection they are in.
+func (c *collectionBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, attrs resourceSdk.SyncOpAttrs) ([]*v2.Resource, *resourceSdk.SyncOpResults, error) {
+	if parentResourceID != nil {
+		return nil, nil, nil
+	}
+
+	ann := annotations.New()
+
+	collections, rateLimitDesc, err := c.client.ListCollections(ctx)
+	if rateLimitDesc != nil {
+		ann.WithRateLimiting(rateLimitDesc)
+	}
+	if err != nil {
+		return nil, &resourceSdk.SyncOpResults{Annotations: ann}, err
+	}
+	putCached(c.cache, attrs.SyncID, collectionIDsCacheKey, collectionIDSet(collections))
+
+	owners := personalCollectionOwners(collections)
+
+	outResources := make([]*v2.Resource, 0, len(collections))
+	for _, collection := range collections {
+		res, err := c.parseIntoCollectionResource(collection, owners)
+		if err != nil {
+			return nil, &resourceSdk.SyncOpResults{Annotations: ann}, err
+		}
+		outResources = append(outResources, res)
+	}
+
+	return outResources, &resourceSdk.SyncOpResults{Annotations: ann}, nil
+}
+
+func (c *collectionBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ resourceSdk.SyncOpAttrs) ([]*v2.Entitlement, *resourceSdk.SyncOpResults, error) {
+	// Permissions cannot be granted on personal collections and their subcollections, which only their owner can
+	// access. Ownership is synced but not provisionable, since Metabase ties each personal collection to its user.
+	if _, ok := personalOwnerID(resource); ok {
+		return []*v2.Entitlement{
+			entitlement.NewAssignmentEntitlement(resource, collectionOwnerPermission,
+				entitlement.WithDisplayName(fmt.Sprintf("%s Owner", resource.DisplayName)),
+				entitlement.WithDescription(fmt.Sprintf("Owns the personal collection %s", resource.DisplayName)),
+			),
+		}, nil, nil
+	}
+
+	rv := make([]*v2.Entitlement, 0, len(collectionPermissions))
+	for _, permission := range collectionPermissions {
+		opts := []entitlement.EntitlementOption{
+			entitlement.WithGrantableTo(baseConnector.GroupResourceType),
+			entitlement.WithDisplayName(fmt.Sprintf("%s %s", resource.DisplayName, permission.DisplayName)),
+			entitlement.WithDescription(fmt.Sprintf("Grants %s access to the %s collection", permission.DisplayName, resource.DisplayName)),
+		}
+		rv = append(rv, entitlement.NewPermissionEntitlement(resource, permission.ID, opts...))
+	}
+
+	return rv, nil, nil
+}
+
+func (c *collectionBuilder) Grants(ctx context.Context, resource *v2.Resource, attrs resourceSdk.SyncOpAttrs) ([]*v2.Grant, *resourceSdk.SyncOpResults, error) {
+	if ownerID, ok := personalOwnerID(resource); ok {
+		userResource := &v2.Resource{
+			Id: &v2.ResourceId{
+				ResourceType: baseConnector.UserResourceType.Id,
+				Resource:     strconv.FormatInt(ownerID, 10),
+			},
+		}
+		return []*v2.Grant{grant.NewGrant(resource, collectionOwnerPermission, userResource)}, nil, nil
+	}
+
+	collectionID := resource.Id.Resource
+	ann := annotations.New()
+
+	graph, err := fetchCached(c.cache, attrs.SyncID, collectionGraphCacheKey, func() (*client.CollectionPermissionGraph, error) {
+		graph, rateLimitDesc, err := c.client.GetCollectionPermissions(ctx)
+		if rateLimitDesc != nil {
+			ann.WithRateLimiting(rateLimitDesc)
+		}
+		return graph, err
+	})
+	if err != nil {
+		return nil, &resourceSdk.SyncOpResults{Annotations: ann}, err
+	}
+
+	var grants []*v2.Grant
+	for groupID, collectionAccess := range graph.Groups {
+		access, ok := collectionAccess[collectionID]
+		if !ok {
+			continue
+		}
+
+		for _, permission := range collectionPermissions {
+			if !collectionAccessIncludes(access, permission.Access) {
+				continue
+			}
+			grants = append(grants, newGroupGrant(resource, permission.ID, groupID, c.client.IsPaidPlan()))
+		}
+	}
+
+	return grants, &resourceSdk.SyncOpResults{Annotations: ann}, nil
+}
+
+func (c *collectionBuilder) Grant(ctx context.Context, principal *v2.Resource, ent *v2.Entitlement) (annotations.Annotations, error) {
+	if principal.Id.ResourceType != baseConnector.GroupResourceType.Id {
+		return nil, fmt.Errorf("baton-metabase-v056: only groups can be granted collection permissions, got %s", principal.Id.ResourceType)
+	}
+
+	access, err := collectionAccessForPermission(permissionFromEntitlement(ent))
+	if err != nil {
+		return nil, err
+	}
+
+	collectionID := ent.Resource.Id.Resource
+	groupID := principal.Id.Resource
+
+	// Granting read access to a group with write access leaves it as is, rather than taking away write access.
+	ann, changed, err := c.updateGroupCollectionAccess(ctx, collectionID, groupID, func(current string) string {
+		if collectionAccessIncludes(current, access) {
+			return ""
+		}
+		return access
+	})
+	if err != nil {
+		return ann, fmt.Errorf("failed to grant %s access on collection %s to group %s: %w", access, collectionID, groupID, err)
+	}
+
+	if !changed {
+		ann.Append(&v2.GrantAlreadyExists{})
+	}
+
+	return ann, nil
+}
+
+func (c *collectionBuilder) Revoke(ctx context.Context, g *v2.Grant) (annotations.Annotations, error) {
+	principal := g.Principal
+	if principal.Id.ResourceType != baseConnector.GroupResourceType.Id {
+		return nil, fmt.Errorf("baton-metabase-v056: only groups can be revoked collection permissions, got %s", principal.Id.ResourceType)
+	}
+
+	access, err := collectionAccessForPermission(permissionFromEntitlement(g.Entitlement))
+	if err != nil {
+		return nil, err
+	}
+
+	collectionID := g.Entitlement.Resource.Id.Resource
+	groupID := principal.Id.Resource
+
+	// Revoking read access from a group with write access takes away write access too, as it is granted with it.
+	ann, changed, err := c.updateGroupCollectionAccess(ctx, collectionID, groupID, func(current string) string {
+		if !collectionAccessIncludes(current, access) {
+			return ""
+		}
+		return collectionNoAccess
+	})
+	if err != nil {
+		return ann, fmt.Errorf("failed to revoke %s access on collection %s from group %s: %w", access, collectionID, groupID, err)
+	}
+
+	if !changed {
+		ann.Append(&v2.GrantAlreadyRevoked{})
+	}
+
+	return ann, nil
+}
+
+// updateGroupCollectionAccess reads the collection graph and writes back the access returned by mutate for the
+// group on the collection. An empty access means the group already has the desired access and nothing is written.
+func (c *collectionBuilder) updateGroupCollectionAccess(
+	ctx context.Context,
+	collectionID string,
+	groupID string,
+	mutate func(current string) string,
+) (annotations.Annotations, bool, error) {
+	ann := annotations.New()
+
+	changed, err := updateGraphWithRetry(ctx, func() (bool, error) {
+		graph, rateLimitDesc, err := c.client.GetCollectionPermissions(ctx)
+		if rateLimitDesc != nil {
+			ann.WithRateLimiting(rateLimitDesc)
+		}
+		if err != nil {
+			return false, err
+		}
+
+		current, ok := graph.Groups[groupID][collectionID]
+		if !ok {
+			current = collectionNoAccess
+		}
+
+		access := mutate(current)
+		if access == "" {
+			return false, nil
+		}
+
+		rateLimitDesc, err = c.client.UpdateCollectionPermissions(ctx, &client.CollectionPermissionGraph{
+			Revision: graph.Revision,
+			Groups: map[string]map[string]string{
+				groupID: {collectionID: access},
+			},
+		})
+		if rateLimitDesc != nil {
+			ann.WithRateLimiting(rateLimitDesc)
+		}
+		if err != nil {
+			return false, err
+		}
+		return true, nil
+	})
+
+	return ann, changed, err
+}
+
+func collectionAccessForPermission(permissionID string) (string, error) {
+	for _, permission := range collectionPermissions {
+		if permission.ID == permissionID {
+			return permission.Access, nil
+		}
+	}
+	return "", fmt.Errorf("baton-metabase-v056: unknown collection permission %s", permissionID)
+}
+
+func (c *collectionBuilder) parseIntoCollectionResource(collection *client.Collection, owners map[string]int) (*v2.Resource, error) {
+	opts := []resourceSdk.ResourceOption{
+		resourceSdk.WithDescription(collection.Description),
+	}
+
+	// Personal collections are not part of the collection tree under the root collection. The owner of the
+	// personal collection that a collection is or is in is kept in the profile, to link it to the owner user.
+	if ownerID, ok := personalCollectionOwner(collection, owners); ok {
+		opts = append(opts, resourceSdk.WithAppTrait(resourceSdk.WithAppProfile(map[string]interface{}{
+			"personal_owner_id": ownerID,
+		})))
+
+		if collection.PersonalOwnerID != nil {
+			return resourceSdk.NewResource(collection.Name, collectionResourceType, string(collection.ID), opts...)
+		}
+	}
+
+	if parentID := parentCollectionID(collection); parentID != "" {
+		opts = append(opts, resourceSdk.WithParentResourceID(&v2.ResourceId{
+			ResourceType: collectionResourceType.Id,
+			Resource:     parentID,
+		}))
+	}
+
+	displayName := collection.Name
+	if collection.ID == client.RootCollectionID {
+		displayName = rootCollectionDisplayName
+	}
+
+	return resourceSdk.NewResource(
+		displayName,
+		collectionResourceType,
+		string(collection.ID),
+		opts...,
+	)
+}
+
+// personalOwnerID returns the ID of the owner of a personal collection, read from the profile of the resource.
+func personalOwnerID(resource *v2.Resource) (int64, bool) {
+	appTrait, err := resourceSdk.GetAppTrait(resource)
+	if err != nil {
+		return 0, false
+	}
+
+	return resourceSdk.GetProfileInt64Value(appTrait.Profile, "personal_owner_id")
+}
+
+// parentCollectionID returns the ID of the parent of the collection, read from its location, which lists the
+// IDs of its ancestors like "/1/5/". Top-level collections are children of the root collection.
+func parentCollectionID(collection *client.Collection) string {
+	if collection.ID == client.RootCollectionID {
+		return ""
+	}
+
+	ancestors := collectionAncestors(collection)
+	if len(ancestors) == 0 {
+		return string(client.RootCollectionID)
+	}
+	return ancestors[len(ancestors)-1]
+}
+
+func collectionAncestors(collection *client.Collection) []string {
+	return strings.FieldsFunc(collection.Location, func(r rune) bool { return r == '/' })
+}
+
+// personalCollectionOwners returns the owner of each personal collection, keyed by collection ID.
+func personalCollectionOwners(collections []*client.Collection) map[string]int {
+	owners := make(map[string]int)
+	for _, collection := range collections {
+		if collection.PersonalOwnerID != nil {
+			owners[string(collection.ID)] = *collection.PersonalOwnerID
+		}
+	}
+	return owners
+}
+
+// personalCollectionOwner returns the owner of the collection if it is a personal collection or is nested in one.
+func personalCollectionOwner(collection *client.Collection, owners map[string]int) (int, bool) {
+	if collection.PersonalOwnerID != nil {
+		return *collection.PersonalOwnerID, true
+	}
+
+	for _, ancestor := range collectionAncestors(collection) {
+		if ownerID, ok := owners[ancestor]; ok {
+			return ownerID, true
+		}
+	}
+	return 0, false
+}
+
+func newCollectionBuilder(client client.ClientService, cache *syncCache) *collectionBuilder {
+	return &collectionBuilder{
+		client: client,
+		cache:  cache,
+	}
+}
@@ -0,0 +1,401 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/conductorone/baton-metabase-v056/pkg/client"
+	baseConnector "github.com/conductorone/baton-metabase/pkg/connector"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func newTestCollectionBuilder() (*collectionBuilder, *client.MockService) {
+	mockClient := &client.MockService{}
+	builder := newCollectionBuilder(mockClient, newSyncCache())
+	return builder, mockClient
+}
+
+func TestCollectionsList(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should list collections with their parent collection", func(t *testing.T) {
+		builder, mockClient := newTestCollectionBuilder()
+		ownerID := 7
+		mockClient.ListCollectionsFunc = func(ctx context.Context) ([]*client.Collection, *v2.RateLimitDescription, error) {
+			return []*client.Collection{
+				{ID: client.RootCollectionID, Name: "Root"},
+				{ID: "1", Name: "Sales", Location: "/"},
+				{ID: "5", Name: "Forecasts", Location: "/1/"},
+				{ID: "9", Name: "Jane's Personal Collection", Location: "/", PersonalOwnerID: &ownerID},
+				{ID: "10", Name: "Drafts", Location: "/9/"},
+			}, nil, nil
+		}
+
+		resources, _, err := builder.List(ctx, nil, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.NoError(t, err)
+		require.Len(t, resources, 5)
+
+		parents := map[string]string{}
+		for _, res := range resources {
+			parents[res.Id.Resource] = res.GetParentResourceId().GetResource()
+		}
+		require.Equal(t, map[string]string{"root": "", "1": "root", "5": "1", "9": "", "10": "9"}, parents)
+		require.Equal(t, rootCollectionDisplayName, resources[0].DisplayName)
+
+		for _, res := range resources {
+			owner, ok := personalOwnerID(res)
+			if res.Id.Resource == "9" || res.Id.Resource == "10" {
+				require.True(t, ok)
+				require.Equal(t, int64(ownerID), owner)
+				continue
+			}
+			require.False(t, ok)
+		}
+	})
+
+	t.Run("should return error if ListCollections fails", func(t *testing.T) {
+		builder, mockClient := newTestCollectionBuilder()
+		mockClient.ListCollectionsFunc = func(ctx context.Context) ([]*client.Collection, *v2.RateLimitDescription, error) {
+			return nil, nil, fmt.Errorf("API error")
+		}
+
+		_, _, err := builder.List(ctx, nil, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.Error(t, err)
+	})
+}
+
+func TestCollectionsGrants(t *testing.T) {
+	ctx := context.Background()
+	collectionResource := &v2.Resource{
+		Id:          &v2.ResourceId{ResourceType: collectionResourceType.Id, Resource: "5"},
+		DisplayName: "Forecasts",
+	}
+
+	t.Run("should map read access to read grants and write access to read and curate grants", func(t *testing.T) {
+		builder, mockClient := newTestCollectionBuilder()
+		mockClient.GetCollectionPermissionsFunc = func(ctx context.Context) (*client.CollectionPermissionGraph, *v2.RateLimitDescription, error) {
+			return &client.CollectionPermissionGraph{Revision: 4, Groups: map[string]map[string]string{
+				"1": {"root": "read", "5": "none"},
+				"3": {"5": "write"},
+				"4": {"5": "read"},
+			}}, nil, nil
+		}
+
+		grants, _, err := builder.Grants(ctx, collectionResource, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.NoError(t, err)
+		require.Equal(t, map[string][]string{
+			"3": {collectionReadPermission, collectionCuratePermission},
+			"4": {collectionReadPermission},
+		}, grantedPermissions(grants))
+	})
+
+	t.Run("should fetch the collection graph once per sync", func(t *testing.T) {
+		builder, mockClient := newTestCollectionBuilder()
+		mockClient.ListCollectionsFunc = func(ctx context.Context) ([]*client.Collection, *v2.RateLimitDescription, error) {
+			return []*client.Collection{{ID: "5", Name: "Forecasts", Location: "/"}, {ID: "6", Name: "Reports", Location: "/"}}, nil, nil
+		}
+		fetches := 0
+		mockClient.GetCollectionPermissionsFunc = func(ctx context.Context) (*client.CollectionPermissionGraph, *v2.RateLimitDescription, error) {
+			fetches++
+			return &client.CollectionPermissionGraph{Revision: 4, Groups: map[string]map[string]string{
+				"3": {"5": "read", "6": "read"},
+			}}, nil, nil
+		}
+
+		sync := func(syncID string) {
+			resources, _, err := builder.List(ctx, nil, resourceSdk.SyncOpAttrs{SyncID: syncID})
+			require.NoError(t, err)
+			for _, resource := range resources {
+				grants, _, err := builder.Grants(ctx, resource, resourceSdk.SyncOpAttrs{SyncID: syncID})
+				require.NoError(t, err)
+				require.Len(t, grants, 1)
+			}
+		}
+
+		sync("sync-1")
+		require.Equal(t, 1, fetches)
+		sync("sync-1")
+		require.Equal(t, 1, fetches)
+		sync("sync-2")
+		require.Equal(t, 2, fetches)
+	})
+
+	t.Run("should return error if GetCollectionPermissions fails", func(t *testing.T) {
+		builder, mockClient := newTestCollectionBuilder()
+		mockClient.GetCollectionPermissionsFunc = func(ctx context.Context) (*client.CollectionPermissionGraph, *v2.RateLimitDescription, error) {
+			return nil, nil, fmt.Errorf("API error")
+		}
+
+		_, _, err := builder.Grants(ctx, collectionResource, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.Error(t, err)
+	})
+}
+
+func TestPersonalCollections(t *testing.T) {
+	ctx := context.Background()
+	ownerID := 7
+
+	builder, mockClient := newTestCollectionBuilder()
+	mockClient.ListCollectionsFunc = func(ctx context.Context) ([]*client.Collection, *v2.RateLimitDescription, error) {
+		return []*client.Collection{
+			{ID: "9", Name: "Jane's Personal Collection", Location: "/", PersonalOwnerID: &ownerID},
+		}, nil, nil
+	}
+
+	resources, _, err := builder.List(ctx, nil, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+	require.NoError(t, err)
+	require.Len(t, resources, 1)
+
+	t.Run("should only offer ownership, which is not grantable", func(t *testing.T) {
+		entitlements, _, err := builder.Entitlements(ctx, resources[0], resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.NoError(t, err)
+		require.Len(t, entitlements, 1)
+		require.Equal(t, "collection:9:owner", entitlements[0].Id)
+		require.Empty(t, entitlements[0].GrantableTo)
+	})
+
+	t.Run("should grant ownership to the owner without reading the collection graph", func(t *testing.T) {
+		grants, _, err := builder.Grants(ctx, resources[0], resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.NoError(t, err)
+		require.Len(t, grants, 1)
+		require.Equal(t, "7", grants[0].Principal.Id.Resource)
+		require.Equal(t, baseConnector.UserResourceType.Id, grants[0].Principal.Id.ResourceType)
+	})
+}
+
+func TestTransferPersonalCollection(t *testing.T) {
+	ctx := context.Background()
+	personalCollectionID := 9
+
+	newArgs := func(userID string, targetCollectionID string) *structpb.Struct {
+		return &structpb.Struct{Fields: map[string]*structpb.Value{
+			"userId":             structpb.NewStringValue(userID),
+			"targetCollectionId": structpb.NewStringValue(targetCollectionID),
+		}}
+	}
+
+	newMockClient := func() *client.MockService {
+		mockClient := newTestClient()
+		mockClient.GetUserFunc = func(ctx context.Context, userID string) (*client.User, *v2.RateLimitDescription, error) {
+			return &client.User{ID: 7, PersonalCollectionID: &personalCollectionID}, nil, nil
+		}
+		return mockClient
+	}
+
+	t.Run("should move the items and count them by model", func(t *testing.T) {
+		mockClient := newMockClient()
+		mockClient.ListCollectionItemsFunc = func(ctx context.Context, collectionID int) ([]*client.CollectionItem, *v2.RateLimitDescription, error) {
+			require.Equal(t, personalCollectionID, collectionID)
+			return []*client.CollectionItem{
+				{ID: 1, Model: "dashboard"},
+				{ID: 2, Model: "card"},
+				{ID: 3, Model: "card"},
+				{ID: 4, Model: "pulse"},
+			}, nil, nil
+		}
+		mockClient.UpdateCollectionItemFunc = func(ctx context.Context, item *client.CollectionItem, update *client.CollectionItemUpdate) (*v2.RateLimitDescription, error) {
+			if item.Model == "pulse" {
+				return nil, client.ErrUnsupportedItem
+			}
+			require.Equal(t, client.CollectionID("12"), update.CollectionID)
+			return nil, nil
+		}
+		c := &Connector{v056Client: mockClient}
+
+		resp, _, err := c.TransferPersonalCollection(ctx, newArgs("7", "12"))
+		require.NoError(t, err)
+		require.Equal(t, float64(3), resp.Fields["moved"].GetNumberValue())
+		movedByModel := resp.Fields["movedByModel"].GetStructValue().Fields
+		require.Equal(t, float64(2), movedByModel["card"].GetNumberValue())
+		require.Equal(t, float64(1), movedByModel["dashboard"].GetNumberValue())
+	})
+
+	t.Run("should not move the items into the personal collection", func(t *testing.T) {
+		c := &Connector{v056Client: newMockClient()}
+
+		_, _, err := c.TransferPersonalCollection(ctx, newArgs("7", "9"))
+		require.Error(t, err)
+	})
+
+	t.Run("should move the items into the root collection", func(t *testing.T) {
+		mockClient := newMockClient()
+		mockClient.ListCollectionItemsFunc = func(ctx context.Context, collectionID int) ([]*client.CollectionItem, *v2.RateLimitDescription, error) {
+			return []*client.CollectionItem{{ID: 1, Model: "dashboard"}}, nil, nil
+		}
+		mockClient.UpdateCollectionItemFunc = func(ctx context.Context, item *client.CollectionItem, update *client.CollectionItemUpdate) (*v2.RateLimitDescription, error) {
+			require.Equal(t, client.RootCollectionID, update.CollectionID)
+			return nil, nil
+		}
+		c := &Connector{v056Client: mockClient}
+
+		resp, _, err := c.TransferPersonalCollection(ctx, newArgs("7", "root"))
+		require.NoError(t, err)
+		require.Equal(t, float64(1), resp.Fields["moved"].GetNumberValue())
+	})
+
+	t.Run("should require a valid target collection", func(t *testing.T) {
+		c := &Connector{v056Client: newMockClient()}
+
+		_, _, err := c.TransferPersonalCollection(ctx, newArgs("7", "0"))
+		require.Error(t, err)
+	})
+}
+
+func TestCollectionsGrant(t *testing.T) {
+	ctx := context.Background()
+	collectionResource := &v2.Resource{
+		Id:          &v2.ResourceId{ResourceType: collectionResourceType.Id, Resource: "5"},
+		DisplayName: "Forecasts",
+	}
+	groupResource := &v2.Resource{
+		Id: &v2.ResourceId{ResourceType: "group", Resource: "3"},
+	}
+	curateEntitlement := &v2.Entitlement{
+		Id:       fmt.Sprintf("%s:5:%s", collectionResourceType.Id, collectionCuratePermission),
+		Resource: collectionResource,
+	}
+
+	t.Run("should write the group access with the current revision", func(t *testing.T) {
+		builder, mockClient := newTestCollectionBuilder()
+		mockClient.GetCollectionPermissionsFunc = func(ctx context.Context) (*client.CollectionPermissionGraph, *v2.RateLimitDescription, error) {
+			return &client.CollectionPermissionGraph{Revision: 4, Groups: map[string]map[string]string{
+				"3": {"5": "read"},
+			}}, nil, nil
+		}
+
+		var updated *client.CollectionPermissionGraph
+		mockClient.UpdateCollectionPermissionsFunc = func(ctx context.Context, graph *client.CollectionPermissionGraph) (*v2.RateLimitDescription, error) {
+			updated = graph
+			return nil, nil
+		}
+
+		ann, err := builder.Grant(ctx, groupResource, curateEntitlement)
+		require.NoError(t, err)
+		require.False(t, ann.Contains(&v2.GrantAlreadyExists{}))
+		require.Equal(t, &client.CollectionPermissionGraph{Revision: 4, Groups: map[string]map[string]string{
+			"3": {"5": "write"},
+		}}, updated)
+	})
+
+	t.Run("should return GrantAlreadyExists if group already has the access", func(t *testing.T) {
+		builder, mockClient := newTestCollectionBuilder()
+		mockClient.GetCollectionPermissionsFunc = func(ctx context.Context) (*client.CollectionPermissionGraph, *v2.RateLimitDescription, error) {
+			return &client.CollectionPermissionGraph{Revision: 4, Groups: map[string]map[string]string{
+				"3": {"5": "write"},
+			}}, nil, nil
+		}
+
+		ann, err := builder.Grant(ctx, groupResource, curateEntitlement)
+		require.NoError(t, err)
+		require.True(t, ann.Contains(&v2.GrantAlreadyExists{}))
+	})
+
+	t.Run("should not take write access away when granting read access", func(t *testing.T) {
+		builder, mockClient := newTestCollectionBuilder()
+		mockClient.GetCollectionPermissionsFunc = func(ctx context.Context) (*client.CollectionPermissionGraph, *v2.RateLimitDescription, error) {
+			return &client.CollectionPermissionGraph{Revision: 4, Groups: map[string]map[string]string{
+				"3": {"5": "write"},
+			}}, nil, nil
+		}
+		mockClient.UpdateCollectionPermissionsFunc = func(ctx context.Context, graph *client.CollectionPermissionGraph) (*v2.RateLimitDescription, error) {
+			require.Fail(t, "write access already includes read access")
+			return nil, nil
+		}
+
+		ann, err := builder.Grant(ctx, groupResource, &v2.Entitlement{
+			Id:       fmt.Sprintf("%s:5:%s", collectionResourceType.Id, collectionReadPermission),
+			Resource: collectionResource,
+		})
+		require.NoError(t, err)
+		require.True(t, ann.Contains(&v2.GrantAlreadyExists{}))
+	})
+
+	t.Run("should re-read the graph and retry on revision conflict", func(t *testing.T) {
+		builder, mockClient := newTestCollectionBuilder()
+		revision := 4
+		mockClient.GetCollectionPermissionsFunc = func(ctx context.Context) (*client.CollectionPermissionGraph, *v2.RateLimitDescription, error) {
+			return &client.CollectionPermissionGraph{Revision: revision, Groups: map[string]map[string]string{}}, nil, nil
+		}
+
+		var revisions []int
+		mockClient.UpdateCollectionPermissionsFunc = func(ctx context.Context, graph *client.CollectionPermissionGraph) (*v2.RateLimitDescription, error) {
+			revisions = append(revisions, graph.Revision)
+			if len(revisions) == 1 {
+				revision = 5
+				return nil, fmt.Errorf("%w: revision mismatch", client.ErrConflict)
+			}
+			return nil, nil
+		}
+
+		_, err := builder.Grant(ctx, groupResource, curateEntitlement)
+		require.NoError(t, err)
+		require.Equal(t, []int{4, 5}, revisions)
+	})
+}
+
+func TestCollectionsRevoke(t *testing.T) {
+	ctx := context.Background()
+	readGrant := &v2.Grant{
+		Entitlement: &v2.Entitlement{
+			Id:       fmt.Sprintf("%s:5:%s", collectionResourceType.Id, collectionReadPermission),
+			Resource: &v2.Resource{Id: &v2.ResourceId{ResourceType: collectionResourceType.Id, Resource: "5"}},
+		},
+		Principal: &v2.Resource{Id: &v2.ResourceId{ResourceType: "group", Resource: "3"}},
+	}
+
+	t.Run("should set the group access to none", func(t *testing.T) {
+		builder, mockClient := newTestCollectionBuilder()
+		mockClient.GetCollectionPermissionsFunc = func(ctx context.Context) (*client.CollectionPermissionGraph, *v2.RateLimitDescription, error) {
+			return &client.CollectionPermissionGraph{Revision: 4, Groups: map[string]map[string]string{
+				"3": {"5": "read"},
+			}}, nil, nil
+		}
+
+		var updated *client.CollectionPermissionGraph
+		mockClient.UpdateCollectionPermissionsFunc = func(ctx context.Context, graph *client.CollectionPermissionGraph) (*v2.RateLimitDescription, error) {
+			updated = graph
+			return nil, nil
+		}
+
+		ann, err := builder.Revoke(ctx, readGrant)
+		require.NoError(t, err)
+		require.False(t, ann.Contains(&v2.GrantAlreadyRevoked{}))
+		require.Equal(t, "none", updated.Groups["3"]["5"])
+	})
+
+	t.Run("should take write access away along with read access", func(t *testing.T) {
+		builder, mockClient := newTestCollectionBuilder()
+		mockClient.GetCollectionPermissionsFunc = func(ctx context.Context) (*client.CollectionPermissionGraph, *v2.RateLimitDescription, error) {
+			return &client.CollectionPermissionGraph{Revision: 4, Groups: map[string]map[string]string{
+				"3": {"5": "write"},
+			}}, nil, nil
+		}
+
+		var updated *client.CollectionPermissionGraph
+		mockClient.UpdateCollectionPermissionsFunc = func(ctx context.Context, graph *client.CollectionPermissionGraph) (*v2.RateLimitDescription, error) {
+			updated = graph
+			return nil, nil
+		}
+
+		_, err := builder.Revoke(ctx, readGrant)
+		require.NoError(t, err)
+		require.Equal(t, "none", updated.Groups["3"]["5"])
+	})
+
+	t.Run("should return GrantAlreadyRevoked if group does not have the access", func(t *testing.T) {
+		builder, mockClient := newTestCollectionBuilder()
+		mockClient.GetCollectionPermissionsFunc = func(ctx context.Context) (*client.CollectionPermissionGraph, *v2.RateLimitDescription, error) {
+			return &client.CollectionPermissionGraph{Revision: 4, Groups: map[string]map[string]string{
+				"3": {"5": "none"},
+			}}, nil, nil
+		}
+
+		ann, err := builder.Revoke(ctx, readGrant)
+		require.NoError(t, err)
+		require.True(t, ann.Contains(&v2.GrantAlreadyRevoked{}))
+	})
+}
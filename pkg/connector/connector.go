@@ -4,12 +4,10 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"strconv"
-	"strings"
+	"time"
 
 	"github.com/conductorone/baton-metabase-v056/pkg/client"
 	cfg "github.com/conductorone/baton-metabase-v056/pkg/config"
-	baseClient "github.com/conductorone/baton-metabase/pkg/client"
 	baseConfig "github.com/conductorone/baton-metabase/pkg/config"
 	baseConnector "github.com/conductorone/baton-metabase/pkg/connector"
 
@@ -21,17 +19,39 @@ import (
 )
 
 type Connector struct {
+	// vBaseConnector provides the metadata of the base connector, like its account creation schema. Everything
+	// else goes through the v0.56 client, which supports both API keys and sessions.
 	vBaseConnector *baseConnector.Connector
-	vBaseClient    *baseClient.MetabaseClient
 	v056Client     client.ClientService
+	deprovision    deprovisionOptions
+	graphOptions   permissionGraphOptions
+	databaseFilter databaseFilter
 }
 
 // ResourceSyncers returns a ResourceSyncer for each resource type that should be synced from the upstream service.
-func (c *Connector) ResourceSyncers(ctx context.Context) []connectorbuilder.ResourceSyncer {
-	syncers := c.vBaseConnector.ResourceSyncers(ctx)
-	syncers = append(syncers,
-		newDatabaseBuilder(c.v056Client),
-	)
+func (c *Connector) ResourceSyncers(ctx context.Context) []connectorbuilder.ResourceSyncerV2 {
+	// The builders share what they read during a sync, like the permission graphs of databases, which schemas and
+	// tables are granted permissions in, the collections that dashboards and cards are placed in, or the group
+	// memberships that admin rights are read from.
+	cache := newSyncCache()
+
+	syncers := []connectorbuilder.ResourceSyncerV2{
+		newUserBuilder(c.v056Client, c.deprovision),
+		newGroupBuilder(c.v056Client, cache),
+		newDatabaseBuilder(c.v056Client, c.graphOptions, c.databaseFilter, cache),
+		newSchemaBuilder(c.v056Client, cache),
+		newTableBuilder(c.v056Client, cache),
+		newCollectionBuilder(c.v056Client, cache),
+		newDashboardBuilder(c.v056Client, cache),
+		newCardBuilder(c.v056Client, cache),
+		newInstanceBuilder(c.v056Client, cache),
+		newAPIKeyBuilder(c.v056Client),
+	}
+
+	// The application permission graph only exists on paid plans.
+	if c.v056Client.IsPaidPlan() {
+		syncers = append(syncers, newApplicationBuilder(c.v056Client))
+	}
 
 	return syncers
 }
@@ -50,7 +70,7 @@ func (c *Connector) Metadata(ctx context.Context) (*v2.ConnectorMetadata, error)
 	}
 
 	baseMeta.DisplayName = "Metabase-v056"
-	baseMeta.Description = "Metabase connector v056 to sync users, groups and databases"
+	baseMeta.Description = "Metabase connector v056 to sync users, groups, databases, schemas, tables, collections, dashboards, cards and API keys"
 
 	return baseMeta, nil
 }
@@ -70,25 +90,22 @@ func (c *Connector) Validate(ctx context.Context) (annotations.Annotations, erro
 		return ann, fmt.Errorf("failed to fetch Metabase version: %w", err)
 	}
 
-	tag := strings.TrimPrefix(versionResp.Tag, "v")
-	parts := strings.Split(tag, ".")
-	if len(parts) < 2 {
-		return ann, fmt.Errorf("unexpected version format: %s", versionResp.Tag)
-	}
-
-	major, err := strconv.Atoi(parts[0])
+	version, err := client.ParseVersion(versionResp.Tag)
 	if err != nil {
-		return ann, fmt.Errorf("invalid major version in tag %s: %w", versionResp.Tag, err)
+		return ann, err
 	}
 
-	minor, err := strconv.Atoi(parts[1])
-	if err != nil {
-		return ann, fmt.Errorf("invalid minor version in tag %s: %w", versionResp.Tag, err)
+	if version.Compare(client.MinimumSupportedVersion) < 0 {
+		return ann, fmt.Errorf("unsupported Metabase version: %s (this connector supports Metabase v0.%d and later)",
+			versionResp.Tag, client.MinimumSupportedVersion.Minor)
 	}
 
-	// Metabase v0.56.x only
-	if major != 0 || minor < 56 || minor >= 57 {
-		return ann, fmt.Errorf("unsupported Metabase version: %s (this connector supports only Metabase v0.56.x)", versionResp.Tag)
+	// Newer releases are synced with the decoders of the latest tested one, which may miss what they changed.
+	if version.Compare(client.LatestTestedVersion) > 0 && !version.SameRelease(client.LatestTestedVersion) {
+		l.Warn("Metabase version is newer than the latest tested version",
+			zap.String("version", versionResp.Tag),
+			zap.String("latest_tested_version", client.LatestTestedVersion.String()),
+		)
 	}
 
 	return ann, nil
@@ -109,21 +126,64 @@ func New(ctx context.Context, config *cfg.MetabaseV056) (*Connector, error) {
 		return nil, err
 	}
 
-	extendedClient, err := client.NewV056Client(ctx, config.MetabaseBaseUrl, config.MetabaseApiKey, config.MetabaseWithPaidPlan)
+	credentials := client.Credentials{
+		APIKey:   config.MetabaseApiKey,
+		Username: config.MetabaseUsername,
+		Password: config.MetabasePassword,
+	}
+
+	retryPolicy := client.RetryPolicy{
+		MaxRetries:     config.MetabaseMaxRetries,
+		InitialBackoff: time.Duration(config.MetabaseRetryInitialBackoffMs) * time.Millisecond,
+	}
+
+	extendedClient, err := client.NewV056Client(ctx, config.MetabaseBaseUrl, credentials, config.MetabaseWithPaidPlan, retryPolicy)
 	if err != nil {
 		l.Error("failed to create extended Metabase v0.56 client", zap.Error(err))
 		return nil, err
 	}
 
-	vBaseClient, err := baseClient.New(ctx, config.MetabaseBaseUrl, config.MetabaseApiKey, config.MetabaseWithPaidPlan)
+	filter, err := newDatabaseFilter(databaseFilter{
+		includeNames:   config.MetabaseIncludeDatabaseNames,
+		excludeNames:   config.MetabaseExcludeDatabaseNames,
+		includeEngines: config.MetabaseIncludeDatabaseEngines,
+		excludeEngines: config.MetabaseExcludeDatabaseEngines,
+		includeIDs:     config.MetabaseIncludeDatabaseIds,
+		excludeIDs:     config.MetabaseExcludeDatabaseIds,
+	})
 	if err != nil {
-		l.Error("failed to create extended Metabase base client", zap.Error(err))
+		l.Error("invalid database filter", zap.Error(err))
 		return nil, err
 	}
 
+	var transferCollectionID client.CollectionID
+	if config.MetabaseDeprovisionTransferCollectionId != "" {
+		transferCollectionID, err = parseTargetCollectionID(config.MetabaseDeprovisionTransferCollectionId)
+		if err != nil {
+			l.Error("invalid deprovision transfer collection", zap.Error(err))
+			return nil, err
+		}
+	}
+
 	return &Connector{
 		vBaseConnector: vBaseConnector,
 		v056Client:     extendedClient,
-		vBaseClient:    vBaseClient,
+		deprovision: deprovisionOptions{
+			removeMemberships:         config.MetabaseDeprovisionRemoveMemberships,
+			archivePersonalCollection: config.MetabaseDeprovisionArchivePersonalCollection,
+			transferCollectionID:      transferCollectionID,
+		},
+		graphOptions: permissionGraphOptions{
+			whole:        config.MetabaseWholePermissionGraph,
+			maxDatabases: config.MetabaseWholePermissionGraphMaxDatabases,
+		},
+		databaseFilter: filter,
 	}, nil
 }
+
+// Close ends the Metabase session when the connector authenticates with a username and password. Requests made
+// afterwards log in again.
+func (c *Connector) Close(ctx context.Context) error {
+	_, err := c.v056Client.Logout(ctx)
+	return err
+}
@@ -10,6 +10,9 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// testSyncID is the ID of the sync that builders are called for, unless a test needs several syncs.
+const testSyncID = "test-sync"
+
 func newTestClient() *client.MockService {
 	return &client.MockService{}
 }
@@ -42,3 +45,38 @@ func TestGetVersion(t *testing.T) {
 		require.Contains(t, err.Error(), "API error")
 	})
 }
+
+func TestValidate(t *testing.T) {
+	ctx := context.Background()
+
+	validate := func(tag string) error {
+		mockClient := newTestClient()
+		mockClient.GetVersionFunc = func(ctx context.Context) (*client.VersionInfo, *v2.RateLimitDescription, error) {
+			return &client.VersionInfo{Tag: tag}, nil, nil
+		}
+
+		c := &Connector{v056Client: mockClient}
+		_, err := c.Validate(ctx)
+		return err
+	}
+
+	t.Run("should accept supported versions of both editions", func(t *testing.T) {
+		require.NoError(t, validate("v0.56.3"))
+		require.NoError(t, validate("v1.56.3.1"))
+		require.NoError(t, validate("v0.49.0"))
+	})
+
+	t.Run("should accept newer versions", func(t *testing.T) {
+		require.NoError(t, validate("v0.58.0-RC1"))
+	})
+
+	t.Run("should reject versions older than the minimum supported one", func(t *testing.T) {
+		err := validate("v0.48.9")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unsupported Metabase version")
+	})
+
+	t.Run("should reject malformed versions", func(t *testing.T) {
+		require.Error(t, validate("vLOCAL_DEV"))
+	})
+}
@@ -0,0 +1,177 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/conductorone/baton-metabase-v056/pkg/client"
+	baseConnector "github.com/conductorone/baton-metabase/pkg/connector"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+)
+
+// creatorPermission is granted to the user that created a dashboard or card.
+const creatorPermission = "creator"
+
+// contentItem holds the fields shared by dashboards and cards, which are synced as children of their collection.
+type contentItem struct {
+	ID              int
+	Name            string
+	Description     string
+	CollectionID    *int
+	CreatorID       int
+	Archived        bool
+	PublicUUID      *string
+	EnableEmbedding bool
+	CreatedAt       string
+	UpdatedAt       string
+}
+
+// updateCollectionItems archives or moves every item of a collection and returns the number of updated items of
+// each model. Items that can be neither archived nor moved, like pulses, are left in place.
+func updateCollectionItems(
+	ctx context.Context,
+	c client.ClientService,
+	collectionID int,
+	update *client.CollectionItemUpdate,
+	ann *annotations.Annotations,
+) (map[string]int, error) {
+	l := ctxzap.Extract(ctx)
+
+	items, rateLimitDesc, err := c.ListCollectionItems(ctx, collectionID)
+	if rateLimitDesc != nil {
+		ann.WithRateLimiting(rateLimitDesc)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	for _, item := range items {
+		rateLimitDesc, err := c.UpdateCollectionItem(ctx, item, update)
+		if rateLimitDesc != nil {
+			ann.WithRateLimiting(rateLimitDesc)
+		}
+		if errors.Is(err, client.ErrUnsupportedItem) {
+			l.Debug("skipping collection item", zap.String("model", item.Model), zap.Int("id", item.ID))
+			continue
+		}
+		if err != nil {
+			return counts, err
+		}
+		counts[item.Model]++
+	}
+
+	return counts, nil
+}
+
+// parseTargetCollectionID returns the collection that content is moved into, which is either a positive collection
+// ID or root.
+func parseTargetCollectionID(value string) (client.CollectionID, error) {
+	if client.CollectionID(value) == client.RootCollectionID {
+		return client.RootCollectionID, nil
+	}
+	if id, err := strconv.Atoi(value); err != nil || id <= 0 {
+		return "", fmt.Errorf("baton-metabase-v056: invalid target collection ID %s", value)
+	}
+	return client.CollectionID(value), nil
+}
+
+// contentCollectionID returns the ID of the collection of a dashboard or card, "root" when it has none.
+func contentCollectionID(item *contentItem) string {
+	if item.CollectionID == nil {
+		return string(client.RootCollectionID)
+	}
+	return strconv.Itoa(*item.CollectionID)
+}
+
+// parseIntoContentResource returns the resource of a dashboard or card, or nil when its collection is not synced,
+// like archived collections. The profile is carried by the app trait, since Metabase content has no trait of its own.
+func parseIntoContentResource(
+	ctx context.Context,
+	item *contentItem,
+	resourceType *v2.ResourceType,
+	collectionIDs map[string]bool,
+	extraProfile map[string]interface{},
+) (*v2.Resource, error) {
+	collectionID := contentCollectionID(item)
+	if !collectionIDs[collectionID] {
+		ctxzap.Extract(ctx).Debug("skipping content outside of the synced collections",
+			zap.String("resource_type", resourceType.Id),
+			zap.Int("id", item.ID),
+			zap.String("collection_id", collectionID),
+		)
+		return nil, nil
+	}
+
+	profile := map[string]interface{}{
+		"name":             item.Name,
+		"collection_id":    collectionID,
+		"creator_id":       item.CreatorID,
+		"archived":         item.Archived,
+		"public_uuid":      "",
+		"public_link":      item.PublicUUID != nil,
+		"enable_embedding": item.EnableEmbedding,
+		"created_at":       item.CreatedAt,
+		"updated_at":       item.UpdatedAt,
+	}
+	if item.PublicUUID != nil {
+		profile["public_uuid"] = *item.PublicUUID
+	}
+	for k, v := range extraProfile {
+		profile[k] = v
+	}
+
+	return resourceSdk.NewResource(
+		item.Name,
+		resourceType,
+		item.ID,
+		resourceSdk.WithDescription(item.Description),
+		resourceSdk.WithParentResourceID(&v2.ResourceId{
+			ResourceType: collectionResourceType.Id,
+			Resource:     collectionID,
+		}),
+		resourceSdk.WithAppTrait(resourceSdk.WithAppProfile(profile)),
+	)
+}
+
+// creatorEntitlements returns the creator entitlement of a dashboard or card, which is synced but not grantable.
+// kind names the resource in the description, e.g. "dashboard".
+func creatorEntitlements(resource *v2.Resource, kind string) []*v2.Entitlement {
+	return []*v2.Entitlement{
+		entitlement.NewAssignmentEntitlement(resource, creatorPermission,
+			entitlement.WithDisplayName(fmt.Sprintf("%s Creator", resource.DisplayName)),
+			entitlement.WithDescription(fmt.Sprintf("Created the %s %s", resource.DisplayName, kind)),
+		),
+	}
+}
+
+// creatorGrants returns the grant of the creator entitlement to the user that created the dashboard or card,
+// read from the profile of the resource.
+func creatorGrants(resource *v2.Resource) ([]*v2.Grant, error) {
+	appTrait, err := resourceSdk.GetAppTrait(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	creatorID, ok := resourceSdk.GetProfileInt64Value(appTrait.Profile, "creator_id")
+	if !ok || creatorID == 0 {
+		return nil, nil
+	}
+
+	userResource := &v2.Resource{
+		Id: &v2.ResourceId{
+			ResourceType: baseConnector.UserResourceType.Id,
+			Resource:     strconv.FormatInt(creatorID, 10),
+		},
+	}
+
+	return []*v2.Grant{grant.NewGrant(resource, creatorPermission, userResource)}, nil
+}
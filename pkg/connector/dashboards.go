@@ -0,0 +1,91 @@
+package connector
+
+import (
+	"context"
+
+	"github.com/conductorone/baton-metabase-v056/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+type dashboardBuilder struct {
+	client client.ClientService
+	cache  *syncCache
+}
+
+func (d *dashboardBuilder) ResourceType(_ context.Context) *v2.ResourceType {
+	return dashboardResourceType
+}
+
+// List returns every dashboard at once, archived ones included, each with its collection as parent.
+func (d *dashboardBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, attrs resourceSdk.SyncOpAttrs) ([]*v2.Resource, *resourceSdk.SyncOpResults, error) {
+	if parentResourceID != nil {
+		return nil, nil, nil
+	}
+
+	ann := annotations.New()
+
+	collectionIDs, err := fetchCollectionIDs(ctx, d.cache, d.client, attrs.SyncID, &ann)
+	if err != nil {
+		return nil, &resourceSdk.SyncOpResults{Annotations: ann}, err
+	}
+
+	var outResources []*v2.Resource
+	for _, archived := range []bool{false, true} {
+		dashboards, rateLimitDesc, err := d.client.ListDashboards(ctx, archived)
+		if rateLimitDesc != nil {
+			ann.WithRateLimiting(rateLimitDesc)
+		}
+		if err != nil {
+			return nil, &resourceSdk.SyncOpResults{Annotations: ann}, err
+		}
+
+		for _, dashboard := range dashboards {
+			res, err := parseIntoContentResource(ctx, dashboardContentItem(dashboard), dashboardResourceType, collectionIDs, nil)
+			if err != nil {
+				return nil, &resourceSdk.SyncOpResults{Annotations: ann}, err
+			}
+			if res != nil {
+				outResources = append(outResources, res)
+			}
+		}
+	}
+
+	return outResources, &resourceSdk.SyncOpResults{Annotations: ann}, nil
+}
+
+func (d *dashboardBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ resourceSdk.SyncOpAttrs) ([]*v2.Entitlement, *resourceSdk.SyncOpResults, error) {
+	return creatorEntitlements(resource, "dashboard"), nil, nil
+}
+
+func (d *dashboardBuilder) Grants(_ context.Context, resource *v2.Resource, _ resourceSdk.SyncOpAttrs) ([]*v2.Grant, *resourceSdk.SyncOpResults, error) {
+	grants, err := creatorGrants(resource)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return grants, nil, nil
+}
+
+func dashboardContentItem(dashboard *client.Dashboard) *contentItem {
+	return &contentItem{
+		ID:              dashboard.ID,
+		Name:            dashboard.Name,
+		Description:     dashboard.Description,
+		CollectionID:    dashboard.CollectionID,
+		CreatorID:       dashboard.CreatorID,
+		Archived:        dashboard.Archived,
+		PublicUUID:      dashboard.PublicUUID,
+		EnableEmbedding: dashboard.EnableEmbedding,
+		CreatedAt:       dashboard.CreatedAt,
+		UpdatedAt:       dashboard.UpdatedAt,
+	}
+}
+
+func newDashboardBuilder(client client.ClientService, cache *syncCache) *dashboardBuilder {
+	return &dashboardBuilder{
+		client: client,
+		cache:  cache,
+	}
+}
@@ -0,0 +1,150 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/conductorone/baton-metabase-v056/pkg/client"
+	baseConnector "github.com/conductorone/baton-metabase/pkg/connector"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDashboardBuilder() (*dashboardBuilder, *client.MockService) {
+	mockClient := &client.MockService{}
+	builder := newDashboardBuilder(mockClient, newSyncCache())
+	return builder, mockClient
+}
+
+func testContentCollections(ctx context.Context) ([]*client.Collection, *v2.RateLimitDescription, error) {
+	ownerID := 7
+	return []*client.Collection{
+		{ID: client.RootCollectionID, Name: "Root"},
+		{ID: "1", Name: "Sales", Location: "/"},
+		{ID: "9", Name: "Jane's Personal Collection", Location: "/", PersonalOwnerID: &ownerID},
+	}, nil, nil
+}
+
+func TestDashboardsList(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should list dashboards of synced collections, archived ones included", func(t *testing.T) {
+		builder, mockClient := newTestDashboardBuilder()
+		salesID := 1
+		personalID := 9
+		archivedCollectionID := 20
+		publicUUID := "a7c1b0d9"
+		mockClient.ListCollectionsFunc = testContentCollections
+		mockClient.ListDashboardsFunc = func(ctx context.Context, archived bool) ([]*client.Dashboard, *v2.RateLimitDescription, error) {
+			if archived {
+				return []*client.Dashboard{{ID: 3, Name: "Old", CollectionID: &salesID, Archived: true}}, nil, nil
+			}
+			return []*client.Dashboard{
+				{ID: 1, Name: "Revenue", CollectionID: &salesID, CreatorID: 4, PublicUUID: &publicUUID},
+				{ID: 2, Name: "Overview"},
+				{ID: 4, Name: "Scratch", CollectionID: &personalID},
+				{ID: 5, Name: "Moved", CollectionID: &archivedCollectionID},
+			}, nil, nil
+		}
+
+		resources, results, err := builder.List(ctx, nil, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.NoError(t, err)
+		nextPageToken := results.NextPageToken
+		require.Empty(t, nextPageToken)
+
+		parents := map[string]string{}
+		for _, res := range resources {
+			parents[res.Id.Resource] = res.GetParentResourceId().GetResource()
+		}
+		require.Equal(t, map[string]string{"1": "1", "2": "root", "3": "1", "4": "9"}, parents)
+
+		appTrait, err := resourceSdk.GetAppTrait(resources[0])
+		require.NoError(t, err)
+		require.True(t, appTrait.Profile.Fields["public_link"].GetBoolValue())
+		require.Equal(t, publicUUID, appTrait.Profile.Fields["public_uuid"].GetStringValue())
+
+		archivedTrait, err := resourceSdk.GetAppTrait(resources[3])
+		require.NoError(t, err)
+		require.True(t, archivedTrait.Profile.Fields["archived"].GetBoolValue())
+	})
+
+	t.Run("should reuse the collections listed by the collection builder", func(t *testing.T) {
+		mockClient := &client.MockService{}
+		listings := 0
+		mockClient.ListCollectionsFunc = func(ctx context.Context) ([]*client.Collection, *v2.RateLimitDescription, error) {
+			listings++
+			return testContentCollections(ctx)
+		}
+		mockClient.ListDashboardsFunc = func(ctx context.Context, archived bool) ([]*client.Dashboard, *v2.RateLimitDescription, error) {
+			return nil, nil, nil
+		}
+		mockClient.ListCardsFunc = func(ctx context.Context, archived bool) ([]*client.Card, *v2.RateLimitDescription, error) {
+			return nil, nil, nil
+		}
+		collections := newSyncCache()
+
+		for sync := 1; sync <= 2; sync++ {
+			_, _, err := newCollectionBuilder(mockClient, collections).List(ctx, nil, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+			require.NoError(t, err)
+			_, _, err = newDashboardBuilder(mockClient, collections).List(ctx, nil, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+			require.NoError(t, err)
+			_, _, err = newCardBuilder(mockClient, collections).List(ctx, nil, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+			require.NoError(t, err)
+			require.Equal(t, sync, listings)
+		}
+	})
+
+	t.Run("should list nothing under a parent", func(t *testing.T) {
+		builder, _ := newTestDashboardBuilder()
+
+		resources, _, err := builder.List(ctx, &v2.ResourceId{ResourceType: collectionResourceType.Id, Resource: "1"}, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.NoError(t, err)
+		require.Empty(t, resources)
+	})
+
+	t.Run("should return error if ListDashboards fails", func(t *testing.T) {
+		builder, mockClient := newTestDashboardBuilder()
+		mockClient.ListCollectionsFunc = testContentCollections
+		mockClient.ListDashboardsFunc = func(ctx context.Context, archived bool) ([]*client.Dashboard, *v2.RateLimitDescription, error) {
+			return nil, nil, fmt.Errorf("API error")
+		}
+
+		_, _, err := builder.List(ctx, nil, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.Error(t, err)
+	})
+}
+
+func TestDashboardsEntitlements(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should offer a creator entitlement that is not grantable", func(t *testing.T) {
+		builder, _ := newTestDashboardBuilder()
+		res := &v2.Resource{Id: &v2.ResourceId{ResourceType: dashboardResourceType.Id, Resource: "1"}, DisplayName: "Revenue"}
+
+		entitlements, _, err := builder.Entitlements(ctx, res, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.NoError(t, err)
+		require.Len(t, entitlements, 1)
+		require.Equal(t, "dashboard:1:creator", entitlements[0].Id)
+		require.Empty(t, entitlements[0].GrantableTo)
+	})
+}
+
+func TestDashboardsGrants(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should grant creator to the user that created the dashboard", func(t *testing.T) {
+		builder, _ := newTestDashboardBuilder()
+		res, err := parseIntoContentResource(ctx, &contentItem{ID: 1, Name: "Revenue", CreatorID: 4}, dashboardResourceType,
+			map[string]bool{string(client.RootCollectionID): true}, nil)
+		require.NoError(t, err)
+
+		grants, _, err := builder.Grants(ctx, res, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.NoError(t, err)
+		require.Len(t, grants, 1)
+		require.Equal(t, baseConnector.UserResourceType.Id, grants[0].Principal.Id.ResourceType)
+		require.Equal(t, "4", grants[0].Principal.Id.Resource)
+		require.Equal(t, creatorPermission, permissionFromEntitlement(grants[0].Entitlement))
+	})
+}
@@ -0,0 +1,60 @@
+package connector
+
+import (
+	"fmt"
+	"path"
+	"slices"
+)
+
+// databaseFilter selects the databases to sync. A database is synced when it matches every include list that is
+// set and none of the exclude lists. Names are matched against glob patterns, engines and IDs exactly.
+type databaseFilter struct {
+	includeNames   []string
+	excludeNames   []string
+	includeEngines []string
+	excludeEngines []string
+	includeIDs     []string
+	excludeIDs     []string
+}
+
+// newDatabaseFilter checks the name patterns, so that a malformed one fails when the connector starts rather than
+// silently matching nothing.
+func newDatabaseFilter(filter databaseFilter) (databaseFilter, error) {
+	for _, pattern := range slices.Concat(filter.includeNames, filter.excludeNames) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return databaseFilter{}, fmt.Errorf("baton-metabase-v056: invalid database name pattern %s: %w", pattern, err)
+		}
+	}
+
+	return filter, nil
+}
+
+// skipReason returns why the database is not synced, or an empty string when it is.
+func (f databaseFilter) skipReason(id string, name string, engine string) string {
+	switch {
+	case len(f.includeIDs) > 0 && !slices.Contains(f.includeIDs, id):
+		return "id not included"
+	case slices.Contains(f.excludeIDs, id):
+		return "id excluded"
+	case len(f.includeEngines) > 0 && !slices.Contains(f.includeEngines, engine):
+		return "engine not included"
+	case slices.Contains(f.excludeEngines, engine):
+		return "engine excluded"
+	case len(f.includeNames) > 0 && !matchesAny(f.includeNames, name):
+		return "name not included"
+	case matchesAny(f.excludeNames, name):
+		return "name excluded"
+	default:
+		return ""
+	}
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		// Patterns are checked by newDatabaseFilter.
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
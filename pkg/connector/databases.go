@@ -2,148 +2,548 @@ package connector
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
 
 	"github.com/conductorone/baton-metabase-v056/pkg/client"
 	baseConnector "github.com/conductorone/baton-metabase/pkg/connector"
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
-	"github.com/conductorone/baton-sdk/pkg/pagination"
-	"github.com/conductorone/baton-sdk/pkg/types/entitlement"
-	"github.com/conductorone/baton-sdk/pkg/types/grant"
 	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
 )
 
+type databaseBuilder struct {
+	client          client.ClientService
+	permissionGraph permissionGraphOptions
+	filter          databaseFilter
+	cache           *syncCache
+}
+
+// permissionGraphOptions select how the permission graphs of databases are fetched.
+type permissionGraphOptions struct {
+	// whole fetches the graph of every database in a single request per sync rather than one request per database.
+	whole bool
+	// maxDatabases is the number of databases above which the graph is fetched per database anyway, if not zero.
+	maxDatabases int
+}
+
+// Keys of the permission graphs and sandboxes that the database, schema and table builders share in the sync cache.
 const (
-	queryBuilderPermission          = "query-builder"
-	queryBuilderAndNativePermission = "query-builder-and-native"
+	// dataGraphRevisionCacheKey keeps the revision of the data permission graph read by List. It is reported in the
+	// profile of the databases, and tells whether the permissions kept in their ETag by the previous sync still hold.
+	dataGraphRevisionCacheKey = "data-graph-revision"
+	// useWholeDataGraphCacheKey keeps whether the sync reads the whole graph, kept under wholeDataGraphCacheKey,
+	// rather than the graph of each database.
+	useWholeDataGraphCacheKey = "data-graph-use-whole"
+	wholeDataGraphCacheKey    = "data-graph"
+	// sandboxesCacheKey keeps the sandboxes of every table on paid plans, keyed by table ID.
+	sandboxesCacheKey = "sandboxes"
 )
 
-type databaseBuilder struct {
-	client client.ClientService
+func databaseGraphCacheKey(dbID string) string {
+	return wholeDataGraphCacheKey + ":" + dbID
+}
+
+// cachedDatabaseGraph returns the graph holding the database if it was already read during the sync.
+func cachedDatabaseGraph(cache *syncCache, syncID string, dbID string) (*client.DBPermissionGraph, bool) {
+	if graph, ok := getCached[*client.DBPermissionGraph](cache, syncID, databaseGraphCacheKey(dbID)); ok {
+		return graph, true
+	}
+	return getCached[*client.DBPermissionGraph](cache, syncID, wholeDataGraphCacheKey)
+}
+
+// fetchDatabaseGraph returns the permission graph holding the database. Unless it was already read during the sync,
+// it fetches either the whole graph once for every database, when the database builder chose to, or the graph of
+// the database alone, and keeps it for the rest of the sync.
+func fetchDatabaseGraph(ctx context.Context, cache *syncCache, cl client.ClientService, syncID string, dbID string, ann *annotations.Annotations) (*client.DBPermissionGraph, error) {
+	if graph, ok := cachedDatabaseGraph(cache, syncID, dbID); ok {
+		return graph, nil
+	}
+
+	if useWhole, _ := getCached[bool](cache, syncID, useWholeDataGraphCacheKey); useWhole {
+		return fetchCached(cache, syncID, wholeDataGraphCacheKey, func() (*client.DBPermissionGraph, error) {
+			graph, rateLimitDesc, err := cl.GetPermissionGraph(ctx)
+			if rateLimitDesc != nil {
+				ann.WithRateLimiting(rateLimitDesc)
+			}
+			return graph, err
+		})
+	}
+
+	return fetchCached(cache, syncID, databaseGraphCacheKey(dbID), func() (*client.DBPermissionGraph, error) {
+		graph, rateLimitDesc, err := cl.GetDBPermissions(ctx, dbID)
+		if rateLimitDesc != nil {
+			ann.WithRateLimiting(rateLimitDesc)
+		}
+		return graph, err
+	})
+}
+
+// fetchSandboxes returns the sandboxes of the table. The sandboxes of every table are listed on first use and kept
+// for the rest of the sync.
+func fetchSandboxes(ctx context.Context, cache *syncCache, cl client.ClientService, syncID string, tableID string, ann *annotations.Annotations) ([]*client.Sandbox, error) {
+	sandboxes, err := fetchCached(cache, syncID, sandboxesCacheKey, func() (map[string][]*client.Sandbox, error) {
+		sandboxList, rateLimitDesc, err := cl.ListSandboxes(ctx, "")
+		if rateLimitDesc != nil {
+			ann.WithRateLimiting(rateLimitDesc)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		sandboxes := make(map[string][]*client.Sandbox)
+		for _, sandbox := range sandboxList {
+			key := strconv.Itoa(sandbox.TableID)
+			sandboxes[key] = append(sandboxes[key], sandbox)
+		}
+		return sandboxes, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sandboxes[tableID], nil
 }
 
 func (d *databaseBuilder) ResourceType(_ context.Context) *v2.ResourceType {
 	return databaseResourceType
 }
 
-func (d *databaseBuilder) List(ctx context.Context, _ *v2.ResourceId, _ *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+func (d *databaseBuilder) List(ctx context.Context, _ *v2.ResourceId, attrs resourceSdk.SyncOpAttrs) ([]*v2.Resource, *resourceSdk.SyncOpResults, error) {
+	opts, err := getPageOptions(&attrs.PageToken, resourcePageSize)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	ann := annotations.New()
 
-	databases, rateLimitDesc, err := d.client.ListDatabases(ctx)
+	databases, total, rateLimitDesc, err := d.client.ListDatabases(ctx, client.DatabaseListOptions{PageOptions: opts})
 	if rateLimitDesc != nil {
 		ann.WithRateLimiting(rateLimitDesc)
 	}
 	if err != nil {
-		return nil, "", ann, err
+		return nil, &resourceSdk.SyncOpResults{Annotations: ann}, err
+	}
+
+	useWhole, err := fetchCached(d.cache, attrs.SyncID, useWholeDataGraphCacheKey, func() (bool, error) {
+		if d.permissionGraph.whole && d.permissionGraph.maxDatabases != 0 && total > d.permissionGraph.maxDatabases {
+			ctxzap.Extract(ctx).Debug("too many databases for the whole permission graph, fetching it per database",
+				zap.Int("databases", total),
+				zap.Int("max_databases", d.permissionGraph.maxDatabases),
+			)
+			return false, nil
+		}
+		return d.permissionGraph.whole, nil
+	})
+	if err != nil {
+		return nil, &resourceSdk.SyncOpResults{Annotations: ann}, err
+	}
+
+	revision, ok := getCached[int](d.cache, attrs.SyncID, dataGraphRevisionCacheKey)
+	if !ok {
+		revision, err = d.readRevision(ctx, attrs.SyncID, databases, useWhole, &ann)
+		if err != nil {
+			return nil, &resourceSdk.SyncOpResults{Annotations: ann}, err
+		}
+		// A page without synced databases reads no revision, which is then read from the next page.
+		if revision != 0 {
+			putCached(d.cache, attrs.SyncID, dataGraphRevisionCacheKey, revision)
+		}
 	}
 
+	l := ctxzap.Extract(ctx)
+
 	outResources := make([]*v2.Resource, 0, len(databases))
 	for _, database := range databases {
-		res, err := d.parseIntoDatabaseResource(database)
+		if reason := d.filter.skipReason(strconv.Itoa(database.ID), database.Name, database.Engine); reason != "" {
+			l.Debug("skipping database",
+				zap.Int("database_id", database.ID),
+				zap.String("database_name", database.Name),
+				zap.String("reason", reason),
+			)
+			continue
+		}
+
+		res, err := d.parseIntoDatabaseResource(database, revision)
 		if err != nil {
-			return nil, "", ann, err
+			return nil, &resourceSdk.SyncOpResults{Annotations: ann}, err
 		}
 		outResources = append(outResources, res)
 	}
 
-	return outResources, "", ann, nil
-}
-
-var databasePermissions = []struct {
-	ID          string
-	DisplayName string
-}{
-	{ID: queryBuilderPermission, DisplayName: "Query Builder"},
-	{ID: queryBuilderAndNativePermission, DisplayName: "Query Builder and Native"},
+	return outResources, &resourceSdk.SyncOpResults{NextPageToken: getNextPageToken(opts.Offset, opts.Limit, total), Annotations: ann}, nil
 }
 
-func (d *databaseBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
-	var rv []*v2.Entitlement
+// readRevision reads the revision of the data permission graph from a graph that the sync needs anyway, and keeps
+// that graph: the whole graph when the sync reads it, or else the graph of the first database of the page that the
+// filter does not exclude, which is the smallest graph carrying the revision. It returns zero when the page has no
+// synced database.
+func (d *databaseBuilder) readRevision(ctx context.Context, syncID string, databases []*client.Database, useWhole bool, ann *annotations.Annotations) (int, error) {
+	if useWhole {
+		graph, rateLimitDesc, err := d.client.GetPermissionGraph(ctx)
+		if rateLimitDesc != nil {
+			ann.WithRateLimiting(rateLimitDesc)
+		}
+		if err != nil {
+			return 0, err
+		}
+		putCached(d.cache, syncID, wholeDataGraphCacheKey, graph)
+		return graph.Revision, nil
+	}
 
-	for _, permission := range databasePermissions {
-		opts := []entitlement.EntitlementOption{
-			entitlement.WithGrantableTo(baseConnector.GroupResourceType),
-			entitlement.WithDisplayName(fmt.Sprintf("%s %s", resource.DisplayName, permission.DisplayName)),
-			entitlement.WithDescription(fmt.Sprintf("Grants %s permission on the %s database", permission.DisplayName, resource.DisplayName)),
+	var dbID string
+	for _, database := range databases {
+		if d.filter.skipReason(strconv.Itoa(database.ID), database.Name, database.Engine) == "" {
+			dbID = strconv.Itoa(database.ID)
+			break
 		}
-		ent := entitlement.NewPermissionEntitlement(resource, permission.ID, opts...)
-		rv = append(rv, ent)
 	}
+	if dbID == "" {
+		return 0, nil
+	}
+
+	graph, rateLimitDesc, err := d.client.GetDBPermissions(ctx, dbID)
+	if rateLimitDesc != nil {
+		ann.WithRateLimiting(rateLimitDesc)
+	}
+	if err != nil {
+		return 0, err
+	}
+	putCached(d.cache, syncID, databaseGraphCacheKey(dbID), graph)
+	return graph.Revision, nil
+}
 
-	return rv, "", nil, nil
+func (d *databaseBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ resourceSdk.SyncOpAttrs) ([]*v2.Entitlement, *resourceSdk.SyncOpResults, error) {
+	return permissionEntitlements(resource, availablePermissions(d.client.IsPaidPlan(), false), "database", true), nil, nil
 }
 
-func (d *databaseBuilder) Grants(ctx context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+func (d *databaseBuilder) Grants(ctx context.Context, resource *v2.Resource, attrs resourceSdk.SyncOpAttrs) ([]*v2.Grant, *resourceSdk.SyncOpResults, error) {
 	dbID := resource.Id.Resource
+
+	if reason := d.filter.skipReason(dbID, resource.DisplayName, databaseEngine(resource)); reason != "" {
+		ctxzap.Extract(ctx).Debug("skipping grants of database",
+			zap.String("database_id", dbID),
+			zap.String("database_name", resource.DisplayName),
+			zap.String("reason", reason),
+		)
+		return nil, nil, nil
+	}
+
 	ann := annotations.New()
 
-	groups, rateLimitDesc, err := d.client.GetDBPermissions(ctx, dbID)
-	if rateLimitDesc != nil {
-		ann.WithRateLimiting(rateLimitDesc)
+	graph, err := d.graph(ctx, attrs.SyncID, resource, &ann)
+	if err != nil {
+		return nil, &resourceSdk.SyncOpResults{Annotations: ann}, err
 	}
+
+	etag, err := newDatabaseGraphETag(graph, dbID)
 	if err != nil {
-		return nil, "", ann, err
+		return nil, &resourceSdk.SyncOpResults{Annotations: ann}, err
 	}
+	ann.Update(etag)
 
 	var grants []*v2.Grant
-	for groupIDStr, dbPermissions := range groups {
+	for groupIDStr, dbPermissions := range graph.Groups {
 		permissions, ok := dbPermissions[dbID]
-		if !ok {
+		if !ok || permissions == nil {
 			continue
 		}
 
-		groupResource := &v2.Resource{
-			Id: &v2.ResourceId{
-				ResourceType: baseConnector.GroupResourceType.Id,
-				Resource:     groupIDStr,
-			},
-		}
+		for _, permission := range availablePermissions(d.client.IsPaidPlan(), false) {
+			if !permission.Dimension.includes(permission.Dimension.get(permissions).GetLevel(), permission.Level) {
+				continue
+			}
 
-		entitlementIDs := []string{
-			fmt.Sprintf("%s:%s:%s", baseConnector.GroupResourceType.Id, groupIDStr, baseConnector.MemberPermission),
+			grants = append(grants, newGroupGrant(resource, permission.ID, groupIDStr, d.client.IsPaidPlan()))
 		}
+	}
 
-		if d.client.IsPaidPlan() {
-			entitlementIDs = append(entitlementIDs,
-				fmt.Sprintf("%s:%s:%s", baseConnector.GroupResourceType.Id, groupIDStr, baseConnector.ManagerPermission),
-			)
+	return grants, &resourceSdk.SyncOpResults{Annotations: ann}, nil
+}
+
+// graph returns the permission graph holding the database. Unless the graph was already read during the sync, the
+// permissions that the previous sync kept in the ETag of the database are used when the revision has not changed
+// since, and shared with the schemas and tables of the database, so that the graph is not fetched again.
+func (d *databaseBuilder) graph(ctx context.Context, syncID string, resource *v2.Resource, ann *annotations.Annotations) (*client.DBPermissionGraph, error) {
+	dbID := resource.Id.Resource
+
+	if graph, ok := cachedDatabaseGraph(d.cache, syncID, dbID); ok {
+		return graph, nil
+	}
+
+	revision, _ := getCached[int](d.cache, syncID, dataGraphRevisionCacheKey)
+	if graph, ok := databaseGraphFromETag(ctx, resource, revision); ok {
+		ctxzap.Extract(ctx).Debug("permission graph unchanged since the previous sync, reusing database permissions",
+			zap.String("database_id", dbID),
+			zap.Int("revision", graph.Revision),
+		)
+		putCached(d.cache, syncID, databaseGraphCacheKey(dbID), graph)
+		return graph, nil
+	}
+
+	return fetchDatabaseGraph(ctx, d.cache, d.client, syncID, dbID, ann)
+}
+
+// newDatabaseGraphETag returns an ETag holding the permissions of every group on the database along with the
+// revision of the graph they were read from. The syncer keeps it on the database for the next sync.
+func newDatabaseGraphETag(graph *client.DBPermissionGraph, dbID string) (*v2.ETag, error) {
+	dbGraph := &client.DBPermissionGraph{
+		Revision: graph.Revision,
+		Groups:   make(map[string]map[string]*client.GroupPermission),
+	}
+	for groupID, dbPermissions := range graph.Groups {
+		if permissions := dbPermissions[dbID]; permissions != nil {
+			dbGraph.Groups[groupID] = map[string]*client.GroupPermission{dbID: permissions}
 		}
+	}
+
+	value, err := json.Marshal(dbGraph)
+	if err != nil {
+		return nil, fmt.Errorf("baton-metabase-v056: failed to encode the permissions of database %s: %w", dbID, err)
+	}
+
+	return &v2.ETag{Value: string(value)}, nil
+}
+
+// databaseGraphFromETag returns the permissions kept in the ETag of the database by the previous sync, if they were
+// read at revision. A zero revision, read from no graph, never matches.
+func databaseGraphFromETag(ctx context.Context, resource *v2.Resource, revision int) (*client.DBPermissionGraph, bool) {
+	if revision == 0 {
+		return nil, false
+	}
+
+	etag := &v2.ETag{}
+	resourceAnn := annotations.Annotations(resource.GetAnnotations())
+	ok, err := resourceAnn.Pick(etag)
+	if err != nil || !ok {
+		return nil, false
+	}
+
+	var graph client.DBPermissionGraph
+	if err := json.Unmarshal([]byte(etag.GetValue()), &graph); err != nil {
+		ctxzap.Extract(ctx).Debug("ignoring unreadable ETag of database", zap.String("database_id", resource.Id.Resource), zap.Error(err))
+		return nil, false
+	}
+
+	if graph.Revision != revision {
+		return nil, false
+	}
+
+	return &graph, true
+}
+
+func (d *databaseBuilder) Grant(ctx context.Context, principal *v2.Resource, ent *v2.Entitlement) (annotations.Annotations, error) {
+	if principal.Id.ResourceType != baseConnector.GroupResourceType.Id {
+		return nil, fmt.Errorf("baton-metabase-v056: only groups can be granted database permissions, got %s", principal.Id.ResourceType)
+	}
+
+	permission, err := findDatabasePermission(permissionFromEntitlement(ent))
+	if err != nil {
+		return nil, err
+	}
+
+	dbID := ent.Resource.Id.Resource
+	groupID := principal.Id.Resource
 
-		if permissions.CreateQueries == queryBuilderPermission {
-			grants = append(grants, grant.NewGrant(resource,
-				queryBuilderPermission,
-				groupResource,
-				grant.WithAnnotation(&v2.GrantExpandable{
-					EntitlementIds: entitlementIDs,
-				}),
-			))
+	ann, changed, err := d.updateGroupDBPermission(ctx, dbID, groupID, func(current *client.GroupPermission) (*client.GroupPermission, error) {
+		if current != nil {
+			value := permission.Dimension.get(current)
+			if value != nil && value.Children != nil {
+				return nil, fmt.Errorf("baton-metabase-v056: group %s has %s permissions set per schema or table, which granting %s would overwrite",
+					groupID, permission.Dimension.Name, permission.ID)
+			}
+			if permission.Dimension.includes(value.GetLevel(), permission.Level) {
+				return nil, nil
+			}
 		}
+		return permission.Dimension.set(permission.Level), nil
+	})
+	if err != nil {
+		return ann, fmt.Errorf("failed to grant %s on database %s to group %s: %w", permission.ID, dbID, groupID, err)
+	}
+
+	if !changed {
+		ann.Append(&v2.GrantAlreadyExists{})
+	}
+
+	return ann, nil
+}
+
+func (d *databaseBuilder) Revoke(ctx context.Context, g *v2.Grant) (annotations.Annotations, error) {
+	principal := g.Principal
+	if principal.Id.ResourceType != baseConnector.GroupResourceType.Id {
+		return nil, fmt.Errorf("baton-metabase-v056: only groups can be revoked database permissions, got %s", principal.Id.ResourceType)
+	}
 
-		if permissions.CreateQueries == queryBuilderAndNativePermission {
-			grants = append(grants, grant.NewGrant(resource,
-				queryBuilderAndNativePermission,
-				groupResource,
-				grant.WithAnnotation(&v2.GrantExpandable{
-					EntitlementIds: entitlementIDs,
-				}),
-			))
+	permission, err := findDatabasePermission(permissionFromEntitlement(g.Entitlement))
+	if err != nil {
+		return nil, err
+	}
+
+	if permission.Dimension.RevokedPaidOnly && !d.client.IsPaidPlan() {
+		return nil, fmt.Errorf("baton-metabase-v056: %s cannot be revoked on free plans, which have no %s %s level", permission.ID, permission.Dimension.Revoked, permission.Dimension.Name)
+	}
+
+	dbID := g.Entitlement.Resource.Id.Resource
+	groupID := principal.Id.Resource
+
+	ann, changed, err := d.updateGroupDBPermission(ctx, dbID, groupID, func(current *client.GroupPermission) (*client.GroupPermission, error) {
+		// Revoking a level that a higher one includes takes away the higher level too, as it is granted with it.
+		if current == nil || !permission.Dimension.includes(permission.Dimension.get(current).GetLevel(), permission.Level) {
+			return nil, nil
 		}
+		return permission.Dimension.set(permission.Dimension.Revoked), nil
+	})
+	if err != nil {
+		return ann, fmt.Errorf("failed to revoke %s on database %s from group %s: %w", permission.ID, dbID, groupID, err)
 	}
 
-	return grants, "", ann, nil
+	if !changed {
+		ann.Append(&v2.GrantAlreadyRevoked{})
+	}
+
+	return ann, nil
 }
 
-func (d *databaseBuilder) parseIntoDatabaseResource(database *client.Database) (*v2.Resource, error) {
+// updateGroupDBPermission reads the permission graph of the database and writes back the change returned by
+// mutate for the group. A nil change means the group already has the desired permission and nothing is written.
+func (d *databaseBuilder) updateGroupDBPermission(
+	ctx context.Context,
+	dbID string,
+	groupID string,
+	mutate func(current *client.GroupPermission) (*client.GroupPermission, error),
+) (annotations.Annotations, bool, error) {
+	ann := annotations.New()
+
+	changed, err := updateGraphWithRetry(ctx, func() (bool, error) {
+		graph, rateLimitDesc, err := d.client.GetDBPermissions(ctx, dbID)
+		if rateLimitDesc != nil {
+			ann.WithRateLimiting(rateLimitDesc)
+		}
+		if err != nil {
+			return false, err
+		}
+
+		change, err := mutate(graph.Groups[groupID][dbID])
+		if err != nil {
+			return false, err
+		}
+		if change == nil {
+			return false, nil
+		}
+
+		rateLimitDesc, err = d.client.UpdatePermissionGraph(ctx, &client.DBPermissionGraph{
+			Revision: graph.Revision,
+			Groups: map[string]map[string]*client.GroupPermission{
+				groupID: {dbID: change},
+			},
+		})
+		if rateLimitDesc != nil {
+			ann.WithRateLimiting(rateLimitDesc)
+		}
+		if err != nil {
+			return false, err
+		}
+		return true, nil
+	})
+
+	return ann, changed, err
+}
+
+// secretParameter matches the secrets passed as parameters of connection strings, like PASSWORD=... in the path
+// of H2 databases.
+var secretParameter = regexp.MustCompile(`(?i)([\w-]*(?:password|pwd|secret|token|key))=[^;&]*`)
+
+// parseIntoDatabaseResource returns the resource of a database. The revision of the data permission graph is kept
+// in the profile, so that a change of the revision between two syncs tells that permissions changed.
+func (d *databaseBuilder) parseIntoDatabaseResource(database *client.Database, revision int) (*v2.Resource, error) {
+	host := databaseDetail(database, "host")
+	dbName := databaseDetail(database, "dbname", "db")
+
+	profile := map[string]interface{}{
+		"engine":                    database.Engine,
+		"host":                      host,
+		"dbname":                    dbName,
+		"is_sample":                 database.IsSample,
+		"is_audit":                  database.IsAudit,
+		"initial_sync_status":       database.InitialSyncStatus,
+		"is_full_sync":              database.IsFullSync,
+		"is_on_demand":              database.IsOnDemand,
+		"permission_graph_revision": revision,
+	}
+	if database.RouterDatabaseID != nil {
+		profile["router_database_id"] = *database.RouterDatabaseID
+	}
+	if database.RouterUserAttribute != nil {
+		profile["router_user_attribute"] = *database.RouterUserAttribute
+	}
+
 	return resourceSdk.NewResource(
 		database.Name,
 		databaseResourceType,
 		database.ID,
+		resourceSdk.WithDescription(databaseDescription(database, host, dbName)),
+		resourceSdk.WithAnnotation(&v2.ChildResourceType{ResourceTypeId: schemaResourceType.Id}),
+		resourceSdk.WithAppTrait(resourceSdk.WithAppProfile(profile)),
 	)
 }
 
-func newDatabaseBuilder(client client.ClientService) *databaseBuilder {
+// databaseDetail returns the first connection detail found among keys, with its secrets redacted.
+func databaseDetail(database *client.Database, keys ...string) string {
+	for _, key := range keys {
+		if value, ok := database.Details[key].(string); ok && value != "" {
+			return redactConnectionDetail(value)
+		}
+	}
+	return ""
+}
+
+// redactConnectionDetail removes the password of connection URLs and the secret parameters of connection strings.
+func redactConnectionDetail(value string) string {
+	if u, err := url.Parse(value); err == nil && u.User != nil {
+		value = u.Redacted()
+	}
+	return secretParameter.ReplaceAllString(value, "${1}=xxxxx")
+}
+
+// databaseDescription describes where the database is, after its description in Metabase if it has one.
+func databaseDescription(database *client.Database, host string, dbName string) string {
+	location := database.Engine
+	switch {
+	case host != "" && dbName != "":
+		location = fmt.Sprintf("%s on %s/%s", database.Engine, host, dbName)
+	case host != "":
+		location = fmt.Sprintf("%s on %s", database.Engine, host)
+	case dbName != "":
+		location = fmt.Sprintf("%s %s", database.Engine, dbName)
+	}
+
+	if database.Description == "" {
+		return location
+	}
+	return fmt.Sprintf("%s (%s)", database.Description, location)
+}
+
+// databaseEngine returns the engine kept in the profile of the database, or an empty string if there is none.
+func databaseEngine(resource *v2.Resource) string {
+	appTrait, err := resourceSdk.GetAppTrait(resource)
+	if err != nil {
+		return ""
+	}
+
+	engine, _ := resourceSdk.GetProfileStringValue(appTrait.Profile, "engine")
+	return engine
+}
+
+func newDatabaseBuilder(client client.ClientService, permissionGraph permissionGraphOptions, filter databaseFilter, cache *syncCache) *databaseBuilder {
 	return &databaseBuilder{
-		client: client,
+		client:          client,
+		permissionGraph: permissionGraph,
+		filter:          filter,
+		cache:           cache,
 	}
 }
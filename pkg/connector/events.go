@@ -0,0 +1,590 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/conductorone/baton-metabase-v056/pkg/client"
+	baseConnector "github.com/conductorone/baton-metabase/pkg/connector"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	auditLogFeedID        = "metabase_audit_log"
+	userActivityFeedID    = "metabase_user_activity"
+	groupMembershipFeedID = "metabase_group_memberships"
+	permissionGraphFeedID = "metabase_permission_graphs"
+
+	eventPageSize = 100
+
+	// defaultEventLookback is how far back the audit log is read when neither a cursor nor a start time is given.
+	defaultEventLookback = 24 * time.Hour
+)
+
+// userLifecycleTopics are the audit log topics reported as changes of the user they are about. Group membership
+// changes made when editing a user are recorded as user-update.
+var userLifecycleTopics = map[string]bool{
+	"user-invited":     true,
+	"user-joined":      true,
+	"user-update":      true,
+	"user-deactivated": true,
+	"user-reactivated": true,
+}
+
+// EventFeeds returns the permission graph and group membership feeds, plus the audit log feed on paid plans, which
+// are the only ones that have an audit log, or the user activity feed on the other plans.
+func (c *Connector) EventFeeds(_ context.Context) []connectorbuilder.EventFeed {
+	feeds := []connectorbuilder.EventFeed{
+		newPermissionGraphFeed(c.v056Client, c.databaseFilter),
+		newGroupMembershipFeed(c.v056Client),
+	}
+
+	if c.v056Client.IsPaidPlan() {
+		feeds = append(feeds, newAuditLogFeed(c.v056Client))
+	} else {
+		feeds = append(feeds, newUserActivityFeed(c.v056Client))
+	}
+
+	return feeds
+}
+
+// auditLogFeed reports user lifecycle events read from the audit log of paid plans.
+type auditLogFeed struct {
+	client client.ClientService
+}
+
+// auditLogCursor is the position in the audit log: the timestamp of the last entry read, and its ID to tell it
+// apart from other entries recorded at the same time. The next page starts right after that entry.
+type auditLogCursor struct {
+	Timestamp time.Time `json:"timestamp"`
+	ID        int       `json:"id"`
+}
+
+func (f *auditLogFeed) EventFeedMetadata(_ context.Context) *v2.EventFeedMetadata {
+	return &v2.EventFeedMetadata{
+		Id:                  auditLogFeedID,
+		SupportedEventTypes: []v2.EventType{v2.EventType_EVENT_TYPE_RESOURCE_CHANGE},
+	}
+}
+
+func (f *auditLogFeed) ListEvents(ctx context.Context, earliestEvent *timestamppb.Timestamp, pToken *pagination.StreamToken) ([]*v2.Event, *pagination.StreamState, annotations.Annotations, error) {
+	ann := annotations.New()
+
+	cursor := &auditLogCursor{}
+	if pToken.Cursor != "" {
+		if err := json.Unmarshal([]byte(pToken.Cursor), cursor); err != nil {
+			return nil, nil, nil, fmt.Errorf("baton-metabase-v056: invalid audit log cursor: %w", err)
+		}
+	}
+
+	if cursor.Timestamp.IsZero() {
+		cursor.Timestamp = time.Now().Add(-defaultEventLookback)
+		if earliestEvent != nil {
+			cursor.Timestamp = earliestEvent.AsTime()
+		}
+	}
+
+	size := pToken.Size
+	if size <= 0 {
+		size = eventPageSize
+	}
+
+	entries, rateLimitDesc, err := f.client.ListAuditLogEntries(ctx, cursor.Timestamp, cursor.ID, size)
+	if rateLimitDesc != nil {
+		ann.WithRateLimiting(rateLimitDesc)
+	}
+	if err != nil {
+		return nil, nil, ann, err
+	}
+
+	next := *cursor
+	var events []*v2.Event
+	for _, entry := range entries {
+		next = auditLogCursor{Timestamp: entry.Timestamp, ID: entry.ID}
+
+		if event := auditLogEvent(entry); event != nil {
+			events = append(events, event)
+		}
+	}
+
+	nextCursor, err := json.Marshal(next)
+	if err != nil {
+		return nil, nil, ann, err
+	}
+
+	return events, &pagination.StreamState{
+		Cursor:  string(nextCursor),
+		HasMore: len(entries) >= size,
+	}, ann, nil
+}
+
+// auditLogEvent returns the event of an audit log entry, or nil when the feed does not report its topic.
+func auditLogEvent(entry *client.AuditLogEntry) *v2.Event {
+	if !userLifecycleTopics[entry.Topic] || entry.EntityID == nil {
+		return nil
+	}
+
+	return &v2.Event{
+		Id:         fmt.Sprintf("%s:%d", auditLogFeedID, entry.ID),
+		OccurredAt: timestamppb.New(entry.Timestamp),
+		Event: &v2.Event_ResourceChangeEvent{
+			ResourceChangeEvent: &v2.ResourceChangeEvent{
+				ResourceId: &v2.ResourceId{
+					ResourceType: baseConnector.UserResourceType.Id,
+					Resource:     strconv.Itoa(*entry.EntityID),
+				},
+			},
+		},
+	}
+}
+
+func newAuditLogFeed(client client.ClientService) *auditLogFeed {
+	return &auditLogFeed{
+		client: client,
+	}
+}
+
+// userActivityFeed reports the users that changed, on plans without an audit log. The /api/activity endpoints of
+// v0.56 only return recently viewed items, so the feed lists the users on each call and compares a fingerprint of
+// each user with the one of the previous call.
+type userActivityFeed struct {
+	client client.ClientService
+}
+
+// userActivityCursor holds the fingerprints of the previous call, keyed by user ID.
+type userActivityCursor struct {
+	Users map[string]uint32 `json:"users"`
+}
+
+func (f *userActivityFeed) EventFeedMetadata(_ context.Context) *v2.EventFeedMetadata {
+	return &v2.EventFeedMetadata{
+		Id:                  userActivityFeedID,
+		SupportedEventTypes: []v2.EventType{v2.EventType_EVENT_TYPE_RESOURCE_CHANGE},
+	}
+}
+
+// ListEvents reports the users whose fingerprint changed. The first call only records the fingerprints.
+func (f *userActivityFeed) ListEvents(ctx context.Context, _ *timestamppb.Timestamp, pToken *pagination.StreamToken) ([]*v2.Event, *pagination.StreamState, annotations.Annotations, error) {
+	ann := annotations.New()
+
+	var previous *userActivityCursor
+	if pToken.Cursor != "" {
+		previous = &userActivityCursor{}
+		if err := json.Unmarshal([]byte(pToken.Cursor), previous); err != nil {
+			return nil, nil, nil, fmt.Errorf("baton-metabase-v056: invalid user activity cursor: %w", err)
+		}
+	}
+
+	current := &userActivityCursor{
+		Users: make(map[string]uint32),
+	}
+
+	opts := client.PageOptions{Limit: eventPageSize}
+	for {
+		users, total, rateLimitDesc, err := f.client.ListUsers(ctx, opts)
+		if rateLimitDesc != nil {
+			ann.WithRateLimiting(rateLimitDesc)
+		}
+		if err != nil {
+			return nil, nil, ann, err
+		}
+
+		for _, user := range users {
+			loginAttributes, err := json.Marshal(user.LoginAttributes)
+			if err != nil {
+				return nil, nil, ann, err
+			}
+
+			current.Users[strconv.Itoa(user.ID)] = fingerprint(
+				strconv.FormatBool(user.IsActive),
+				strconv.FormatBool(user.IsSuperuser),
+				user.Email,
+				user.FirstName,
+				user.LastName,
+				string(loginAttributes),
+			)
+		}
+
+		opts.Offset += len(users)
+		if len(users) == 0 || opts.Offset >= total {
+			break
+		}
+	}
+
+	var events []*v2.Event
+	if previous != nil {
+		events = fingerprintEvents(userActivityFeedID, timestamppb.Now(), baseConnector.UserResourceType, previous.Users, current.Users)
+	}
+
+	nextCursor, err := json.Marshal(current)
+	if err != nil {
+		return nil, nil, ann, err
+	}
+
+	return events, &pagination.StreamState{Cursor: string(nextCursor)}, ann, nil
+}
+
+func newUserActivityFeed(client client.ClientService) *userActivityFeed {
+	return &userActivityFeed{
+		client: client,
+	}
+}
+
+// groupMembershipFeed reports the groups whose members changed, on every plan, since the audit log records a
+// membership change as an update of the user rather than of the group. It lists the memberships on each call and
+// compares a fingerprint of the members of each group with the one of the previous call.
+type groupMembershipFeed struct {
+	client client.ClientService
+}
+
+// groupMembershipCursor holds the fingerprints of the previous call, keyed by group ID.
+type groupMembershipCursor struct {
+	Groups map[string]uint32 `json:"groups"`
+}
+
+func (f *groupMembershipFeed) EventFeedMetadata(_ context.Context) *v2.EventFeedMetadata {
+	return &v2.EventFeedMetadata{
+		Id:                  groupMembershipFeedID,
+		SupportedEventTypes: []v2.EventType{v2.EventType_EVENT_TYPE_RESOURCE_CHANGE},
+	}
+}
+
+// ListEvents reports the groups whose fingerprint changed. The first call only records the fingerprints.
+func (f *groupMembershipFeed) ListEvents(ctx context.Context, _ *timestamppb.Timestamp, pToken *pagination.StreamToken) ([]*v2.Event, *pagination.StreamState, annotations.Annotations, error) {
+	ann := annotations.New()
+
+	var previous *groupMembershipCursor
+	if pToken.Cursor != "" {
+		previous = &groupMembershipCursor{}
+		if err := json.Unmarshal([]byte(pToken.Cursor), previous); err != nil {
+			return nil, nil, nil, fmt.Errorf("baton-metabase-v056: invalid group membership cursor: %w", err)
+		}
+	}
+
+	memberships, rateLimitDesc, err := f.client.ListMemberships(ctx)
+	if rateLimitDesc != nil {
+		ann.WithRateLimiting(rateLimitDesc)
+	}
+	if err != nil {
+		return nil, nil, ann, err
+	}
+
+	members := make(map[string][]string)
+	for _, userMemberships := range memberships {
+		for _, membership := range userMemberships {
+			groupID := strconv.Itoa(membership.GroupID)
+			members[groupID] = append(members[groupID], fmt.Sprintf("%d:%t", membership.UserID, membership.IsGroupManager))
+		}
+	}
+
+	current := &groupMembershipCursor{
+		Groups: make(map[string]uint32, len(members)),
+	}
+	for groupID, groupMembers := range members {
+		sort.Strings(groupMembers)
+		current.Groups[groupID] = fingerprint(groupMembers...)
+	}
+
+	var events []*v2.Event
+	if previous != nil {
+		events = fingerprintEvents(groupMembershipFeedID, timestamppb.Now(), baseConnector.GroupResourceType, previous.Groups, current.Groups)
+	}
+
+	nextCursor, err := json.Marshal(current)
+	if err != nil {
+		return nil, nil, ann, err
+	}
+
+	return events, &pagination.StreamState{Cursor: string(nextCursor)}, ann, nil
+}
+
+func newGroupMembershipFeed(client client.ClientService) *groupMembershipFeed {
+	return &groupMembershipFeed{
+		client: client,
+	}
+}
+
+// fingerprint hashes the values that a change of a resource is noticed by.
+func fingerprint(values ...string) uint32 {
+	h := fnv.New32a()
+	for _, value := range values {
+		_, _ = h.Write([]byte(value))
+		_, _ = h.Write([]byte{0})
+	}
+	return h.Sum32()
+}
+
+// fingerprintEvents returns a change event, in ID order, for each resource that was added, removed, or whose
+// fingerprint changed.
+func fingerprintEvents(feedID string, occurredAt *timestamppb.Timestamp, resourceType *v2.ResourceType, previous map[string]uint32, current map[string]uint32) []*v2.Event {
+	changed := changedFingerprints(previous, current)
+
+	events := make([]*v2.Event, 0, len(changed))
+	for _, resourceID := range changed {
+		events = append(events, &v2.Event{
+			Id:         fmt.Sprintf("%s:%s:%s:%d", feedID, resourceType.Id, resourceID, current[resourceID]),
+			OccurredAt: occurredAt,
+			Event: &v2.Event_ResourceChangeEvent{
+				ResourceChangeEvent: &v2.ResourceChangeEvent{
+					ResourceId: &v2.ResourceId{
+						ResourceType: resourceType.Id,
+						Resource:     resourceID,
+					},
+				},
+			},
+		})
+	}
+	return events
+}
+
+// changedFingerprints returns the sorted IDs that were added, removed, or whose fingerprint changed.
+func changedFingerprints(previous map[string]uint32, current map[string]uint32) []string {
+	var changed []string
+	for id, value := range current {
+		if previousValue, ok := previous[id]; !ok || previousValue != value {
+			changed = append(changed, id)
+		}
+	}
+	for id := range previous {
+		if _, ok := current[id]; !ok {
+			changed = append(changed, id)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// permissionGraphFeed reports the databases and collections whose permissions changed, and the application when
+// its permission graph changed. It works on every plan, since it does not depend on the audit log. Databases that
+// the filter excludes are not synced, so their changes are not reported.
+type permissionGraphFeed struct {
+	client client.ClientService
+	filter databaseFilter
+}
+
+// permissionGraphCursor holds the last revision seen of each permission graph, and a fingerprint of the
+// permissions of each database and collection, keyed by ID.
+type permissionGraphCursor struct {
+	Data        int               `json:"data"`
+	Collection  int               `json:"collection"`
+	Application int               `json:"application,omitempty"`
+	Databases   map[string]uint32 `json:"databases,omitempty"`
+	Collections map[string]uint32 `json:"collections,omitempty"`
+}
+
+func (f *permissionGraphFeed) EventFeedMetadata(_ context.Context) *v2.EventFeedMetadata {
+	return &v2.EventFeedMetadata{
+		Id:                  permissionGraphFeedID,
+		SupportedEventTypes: []v2.EventType{v2.EventType_EVENT_TYPE_RESOURCE_CHANGE},
+	}
+}
+
+// ListEvents reports the objects whose fingerprint changed. The data graph is only read database by database when
+// its revision changed since the previous call. The first call, and the first call after a cursor without
+// fingerprints, only record them.
+func (f *permissionGraphFeed) ListEvents(ctx context.Context, _ *timestamppb.Timestamp, pToken *pagination.StreamToken) ([]*v2.Event, *pagination.StreamState, annotations.Annotations, error) {
+	ann := annotations.New()
+
+	var previous *permissionGraphCursor
+	if pToken.Cursor != "" {
+		previous = &permissionGraphCursor{}
+		if err := json.Unmarshal([]byte(pToken.Cursor), previous); err != nil {
+			return nil, nil, nil, fmt.Errorf("baton-metabase-v056: invalid permission graph cursor: %w", err)
+		}
+	}
+
+	current := &permissionGraphCursor{}
+	now := timestamppb.Now()
+	var events []*v2.Event
+
+	databases, err := f.syncedDatabases(ctx, &ann)
+	if err != nil {
+		return nil, nil, ann, err
+	}
+
+	if len(databases) > 0 {
+		first, err := f.databaseGraph(ctx, strconv.Itoa(databases[0].ID), &ann)
+		if err != nil {
+			return nil, nil, ann, err
+		}
+		current.Data = first.Revision
+
+		if previous != nil && previous.Databases != nil && current.Data == previous.Data {
+			current.Databases = previous.Databases
+		} else {
+			current.Databases, err = f.databaseFingerprints(ctx, databases, first, &ann)
+			if err != nil {
+				return nil, nil, ann, err
+			}
+		}
+	}
+
+	if previous != nil && previous.Databases != nil {
+		for _, databaseID := range changedFingerprints(previous.Databases, current.Databases) {
+			events = append(events, permissionGraphEvent(now, "data", current.Data, databaseResourceType, databaseID))
+		}
+	}
+
+	collectionGraph, rateLimitDesc, err := f.client.GetCollectionPermissions(ctx)
+	if rateLimitDesc != nil {
+		ann.WithRateLimiting(rateLimitDesc)
+	}
+	if err != nil {
+		return nil, nil, ann, err
+	}
+	current.Collection = collectionGraph.Revision
+	current.Collections = collectionFingerprints(collectionGraph.Groups)
+
+	if previous != nil && previous.Collections != nil {
+		for _, collectionID := range changedFingerprints(previous.Collections, current.Collections) {
+			events = append(events, permissionGraphEvent(now, "collection", current.Collection, collectionResourceType, collectionID))
+		}
+	}
+
+	if f.client.IsPaidPlan() {
+		applicationGraph, rateLimitDesc, err := f.client.GetApplicationPermissions(ctx)
+		if rateLimitDesc != nil {
+			ann.WithRateLimiting(rateLimitDesc)
+		}
+		if err != nil {
+			return nil, nil, ann, err
+		}
+		current.Application = applicationGraph.Revision
+
+		if previous != nil && current.Application != previous.Application {
+			events = append(events, permissionGraphEvent(now, "application", current.Application, applicationResourceType, applicationID))
+		}
+	}
+
+	nextCursor, err := json.Marshal(current)
+	if err != nil {
+		return nil, nil, ann, err
+	}
+
+	return events, &pagination.StreamState{Cursor: string(nextCursor)}, ann, nil
+}
+
+// syncedDatabases returns every database that the filter does not exclude, listed page by page as the database
+// builder lists them.
+func (f *permissionGraphFeed) syncedDatabases(ctx context.Context, ann *annotations.Annotations) ([]*client.Database, error) {
+	var synced []*client.Database
+	pToken := &pagination.Token{}
+	for {
+		opts, err := getPageOptions(pToken, resourcePageSize)
+		if err != nil {
+			return nil, err
+		}
+
+		databases, total, rateLimitDesc, err := f.client.ListDatabases(ctx, client.DatabaseListOptions{PageOptions: opts})
+		if rateLimitDesc != nil {
+			ann.WithRateLimiting(rateLimitDesc)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, database := range databases {
+			if f.filter.skipReason(strconv.Itoa(database.ID), database.Name, database.Engine) == "" {
+				synced = append(synced, database)
+			}
+		}
+
+		pToken.Token = getNextPageToken(opts.Offset, opts.Limit, total)
+		if pToken.Token == "" || len(databases) == 0 {
+			return synced, nil
+		}
+	}
+}
+
+func (f *permissionGraphFeed) databaseGraph(ctx context.Context, dbID string, ann *annotations.Annotations) (*client.DBPermissionGraph, error) {
+	graph, rateLimitDesc, err := f.client.GetDBPermissions(ctx, dbID)
+	if rateLimitDesc != nil {
+		ann.WithRateLimiting(rateLimitDesc)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return graph, nil
+}
+
+// databaseFingerprints returns the fingerprint of the permissions of each database, reading the graph of each one
+// but the first, whose graph was already read for the revision.
+func (f *permissionGraphFeed) databaseFingerprints(ctx context.Context, databases []*client.Database, first *client.DBPermissionGraph, ann *annotations.Annotations) (map[string]uint32, error) {
+	fingerprints := make(map[string]uint32, len(databases))
+	for i, database := range databases {
+		dbID := strconv.Itoa(database.ID)
+
+		graph := first
+		if i > 0 {
+			var err error
+			graph, err = f.databaseGraph(ctx, dbID, ann)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		var permissions []string
+		for groupID, groupDatabases := range graph.Groups {
+			permission, ok := groupDatabases[dbID]
+			if !ok {
+				continue
+			}
+			value, err := json.Marshal(permission)
+			if err != nil {
+				return nil, err
+			}
+			permissions = append(permissions, groupID+"="+string(value))
+		}
+		sort.Strings(permissions)
+		fingerprints[dbID] = fingerprint(permissions...)
+	}
+	return fingerprints, nil
+}
+
+func permissionGraphEvent(occurredAt *timestamppb.Timestamp, graph string, revision int, resourceType *v2.ResourceType, resourceID string) *v2.Event {
+	return &v2.Event{
+		Id:         fmt.Sprintf("%s:%s:%d:%s", permissionGraphFeedID, graph, revision, resourceID),
+		OccurredAt: occurredAt,
+		Event: &v2.Event_ResourceChangeEvent{
+			ResourceChangeEvent: &v2.ResourceChangeEvent{
+				ResourceId: &v2.ResourceId{
+					ResourceType: resourceType.Id,
+					Resource:     resourceID,
+				},
+			},
+		},
+	}
+}
+
+// collectionFingerprints returns the fingerprint of the access of every group to each collection.
+func collectionFingerprints(groups map[string]map[string]string) map[string]uint32 {
+	accesses := make(map[string][]string)
+	for groupID, collections := range groups {
+		for collectionID, access := range collections {
+			accesses[collectionID] = append(accesses[collectionID], groupID+"="+access)
+		}
+	}
+
+	fingerprints := make(map[string]uint32, len(accesses))
+	for collectionID, collectionAccesses := range accesses {
+		sort.Strings(collectionAccesses)
+		fingerprints[collectionID] = fingerprint(collectionAccesses...)
+	}
+	return fingerprints
+}
+
+func newPermissionGraphFeed(client client.ClientService, filter databaseFilter) *permissionGraphFeed {
+	return &permissionGraphFeed{
+		client: client,
+		filter: filter,
+	}
+}
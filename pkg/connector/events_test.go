@@ -0,0 +1,379 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/conductorone/baton-metabase-v056/pkg/client"
+	baseConnector "github.com/conductorone/baton-metabase/pkg/connector"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestAuditLogFeedListEvents(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	userID := 7
+
+	t.Run("should report user lifecycle events from the start time", func(t *testing.T) {
+		mockClient := &client.MockService{}
+		feed := newAuditLogFeed(mockClient)
+		mockClient.ListAuditLogEntriesFunc = func(ctx context.Context, afterTimestamp time.Time, afterID int, limit int) ([]*client.AuditLogEntry, *v2.RateLimitDescription, error) {
+			require.True(t, afterTimestamp.Equal(start))
+			require.Zero(t, afterID)
+			require.Equal(t, 2, limit)
+			return []*client.AuditLogEntry{
+				{ID: 1, Topic: "user-deactivated", Timestamp: start, EntityType: "user", EntityID: &userID},
+				{ID: 2, Topic: "card-create", Timestamp: start.Add(time.Minute), EntityType: "card"},
+			}, nil, nil
+		}
+
+		events, state, _, err := feed.ListEvents(ctx, timestamppb.New(start), &pagination.StreamToken{Size: 2})
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		require.Equal(t, baseConnector.UserResourceType.Id, events[0].GetResourceChangeEvent().GetResourceId().GetResourceType())
+		require.Equal(t, "7", events[0].GetResourceChangeEvent().GetResourceId().GetResource())
+		require.True(t, state.HasMore)
+
+		var cursor auditLogCursor
+		require.NoError(t, json.Unmarshal([]byte(state.Cursor), &cursor))
+		require.Equal(t, 2, cursor.ID)
+		require.True(t, cursor.Timestamp.Equal(start.Add(time.Minute)))
+	})
+
+	t.Run("should read the entries that follow the cursor", func(t *testing.T) {
+		mockClient := &client.MockService{}
+		feed := newAuditLogFeed(mockClient)
+		mockClient.ListAuditLogEntriesFunc = func(ctx context.Context, afterTimestamp time.Time, afterID int, limit int) ([]*client.AuditLogEntry, *v2.RateLimitDescription, error) {
+			require.True(t, afterTimestamp.Equal(start))
+			require.Equal(t, 1, afterID)
+			return []*client.AuditLogEntry{
+				{ID: 3, Topic: "user-update", Timestamp: start, EntityID: &userID},
+			}, nil, nil
+		}
+
+		cursor, err := json.Marshal(auditLogCursor{Timestamp: start, ID: 1})
+		require.NoError(t, err)
+
+		events, state, _, err := feed.ListEvents(ctx, nil, &pagination.StreamToken{Cursor: string(cursor)})
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		require.Equal(t, "metabase_audit_log:3", events[0].Id)
+		require.False(t, state.HasMore)
+	})
+
+	t.Run("should page through more entries recorded at the same time than fit in a page", func(t *testing.T) {
+		var entries []*client.AuditLogEntry
+		for id := 1; id <= 5; id++ {
+			entries = append(entries, &client.AuditLogEntry{ID: id, Topic: "user-invited", Timestamp: start, EntityID: &userID})
+		}
+
+		mockClient := &client.MockService{}
+		feed := newAuditLogFeed(mockClient)
+		mockClient.ListAuditLogEntriesFunc = func(ctx context.Context, afterTimestamp time.Time, afterID int, limit int) ([]*client.AuditLogEntry, *v2.RateLimitDescription, error) {
+			var page []*client.AuditLogEntry
+			for _, entry := range entries {
+				after := entry.Timestamp.After(afterTimestamp) || (entry.Timestamp.Equal(afterTimestamp) && entry.ID > afterID)
+				if after && len(page) < limit {
+					page = append(page, entry)
+				}
+			}
+			return page, nil, nil
+		}
+
+		var eventIDs []string
+		token := &pagination.StreamToken{Size: 2}
+		for calls := 0; calls < 5; calls++ {
+			events, state, _, err := feed.ListEvents(ctx, timestamppb.New(start), token)
+			require.NoError(t, err)
+			for _, event := range events {
+				eventIDs = append(eventIDs, event.Id)
+			}
+			if !state.HasMore {
+				break
+			}
+			token = &pagination.StreamToken{Size: 2, Cursor: state.Cursor}
+		}
+
+		require.Equal(t, []string{
+			"metabase_audit_log:1",
+			"metabase_audit_log:2",
+			"metabase_audit_log:3",
+			"metabase_audit_log:4",
+			"metabase_audit_log:5",
+		}, eventIDs)
+	})
+}
+
+func TestUserActivityFeedListEvents(t *testing.T) {
+	ctx := context.Background()
+
+	newMockClient := func(users []*client.User) *client.MockService {
+		return &client.MockService{
+			ListUsersFunc: func(ctx context.Context, opts client.PageOptions) ([]*client.User, int, *v2.RateLimitDescription, error) {
+				end := min(opts.Offset+opts.Limit, len(users))
+				return users[opts.Offset:end], len(users), nil, nil
+			},
+		}
+	}
+
+	users := []*client.User{
+		{ID: 1, Email: "admin@example.com", IsActive: true, IsSuperuser: true},
+		{ID: 7, Email: "user@example.com", IsActive: true},
+	}
+
+	t.Run("should only record fingerprints on the first call", func(t *testing.T) {
+		feed := newUserActivityFeed(newMockClient(users))
+
+		events, state, _, err := feed.ListEvents(ctx, nil, &pagination.StreamToken{})
+		require.NoError(t, err)
+		require.Empty(t, events)
+
+		cursor := &userActivityCursor{}
+		require.NoError(t, json.Unmarshal([]byte(state.Cursor), cursor))
+		require.Len(t, cursor.Users, 2)
+	})
+
+	t.Run("should report the users that changed", func(t *testing.T) {
+		feed := newUserActivityFeed(newMockClient(users))
+		_, state, _, err := feed.ListEvents(ctx, nil, &pagination.StreamToken{})
+		require.NoError(t, err)
+
+		changedUsers := []*client.User{
+			users[0],
+			{ID: 7, Email: "user@example.com", IsActive: false},
+		}
+		feed = newUserActivityFeed(newMockClient(changedUsers))
+
+		events, _, _, err := feed.ListEvents(ctx, nil, &pagination.StreamToken{Cursor: state.Cursor})
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		require.Equal(t, baseConnector.UserResourceType.Id, events[0].GetResourceChangeEvent().GetResourceId().GetResourceType())
+		require.Equal(t, "7", events[0].GetResourceChangeEvent().GetResourceId().GetResource())
+	})
+}
+
+func TestGroupMembershipFeedListEvents(t *testing.T) {
+	ctx := context.Background()
+
+	newMockClient := func(memberships map[string][]*client.Membership) *client.MockService {
+		return &client.MockService{
+			ListMembershipsFunc: func(ctx context.Context) (map[string][]*client.Membership, *v2.RateLimitDescription, error) {
+				return memberships, nil, nil
+			},
+		}
+	}
+
+	memberships := map[string][]*client.Membership{
+		"1": {{GroupID: 1, UserID: 1}, {GroupID: 2, UserID: 1}},
+		"7": {{GroupID: 1, UserID: 7}},
+	}
+
+	t.Run("should only record fingerprints on the first call", func(t *testing.T) {
+		feed := newGroupMembershipFeed(newMockClient(memberships))
+
+		events, state, _, err := feed.ListEvents(ctx, nil, &pagination.StreamToken{})
+		require.NoError(t, err)
+		require.Empty(t, events)
+
+		cursor := &groupMembershipCursor{}
+		require.NoError(t, json.Unmarshal([]byte(state.Cursor), cursor))
+		require.Len(t, cursor.Groups, 2)
+	})
+
+	t.Run("should report the groups whose members changed", func(t *testing.T) {
+		feed := newGroupMembershipFeed(newMockClient(memberships))
+		_, state, _, err := feed.ListEvents(ctx, nil, &pagination.StreamToken{})
+		require.NoError(t, err)
+
+		changedMemberships := map[string][]*client.Membership{
+			"1": {{GroupID: 1, UserID: 1}, {GroupID: 2, UserID: 1, IsGroupManager: true}},
+			"7": {{GroupID: 1, UserID: 7}, {GroupID: 3, UserID: 7}},
+		}
+		feed = newGroupMembershipFeed(newMockClient(changedMemberships))
+
+		events, _, _, err := feed.ListEvents(ctx, nil, &pagination.StreamToken{Cursor: state.Cursor})
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		require.Equal(t, baseConnector.GroupResourceType.Id, events[0].GetResourceChangeEvent().GetResourceId().GetResourceType())
+		require.Equal(t, "2", events[0].GetResourceChangeEvent().GetResourceId().GetResource())
+		require.Equal(t, "3", events[1].GetResourceChangeEvent().GetResourceId().GetResource())
+		require.Contains(t, events[1].GetId(), "metabase_group_memberships:group:3:")
+	})
+}
+
+func TestPermissionGraphFeedListEvents(t *testing.T) {
+	ctx := context.Background()
+
+	newGraphs := func() (*client.DBPermissionGraph, *client.CollectionPermissionGraph) {
+		data := &client.DBPermissionGraph{
+			Revision: 10,
+			Groups: map[string]map[string]*client.GroupPermission{
+				"1": {
+					"1": {ViewData: client.PermissionLevel("unrestricted"), CreateQueries: client.PermissionLevel("query-builder")},
+					"2": {ViewData: client.PermissionLevel("unrestricted"), CreateQueries: client.PermissionLevel("no")},
+				},
+			},
+		}
+		collection := &client.CollectionPermissionGraph{
+			Revision: 4,
+			Groups: map[string]map[string]string{
+				"1": {"root": "read", "5": "none"},
+				"3": {"root": "write"},
+			},
+		}
+		return data, collection
+	}
+
+	// newMockClient serves the current state of the graphs, so that a test can change them between calls, and
+	// records the databases whose graph was read.
+	newMockClient := func(data *client.DBPermissionGraph, collection *client.CollectionPermissionGraph, reads *[]string) *client.MockService {
+		return &client.MockService{
+			GetDBPermissionsFunc: func(ctx context.Context, dbID string) (*client.DBPermissionGraph, *v2.RateLimitDescription, error) {
+				*reads = append(*reads, dbID)
+				graph := &client.DBPermissionGraph{Revision: data.Revision, Groups: make(map[string]map[string]*client.GroupPermission)}
+				for groupID, databases := range data.Groups {
+					if permission, ok := databases[dbID]; ok {
+						graph.Groups[groupID] = map[string]*client.GroupPermission{dbID: permission}
+					}
+				}
+				return graph, nil, nil
+			},
+			GetCollectionPermissionsFunc: func(ctx context.Context) (*client.CollectionPermissionGraph, *v2.RateLimitDescription, error) {
+				return collection, nil, nil
+			},
+			ListDatabasesFunc: func(ctx context.Context, opts client.DatabaseListOptions) ([]*client.Database, int, *v2.RateLimitDescription, error) {
+				databases := []*client.Database{{ID: 1}, {ID: 2}}
+				end := min(opts.Offset+opts.Limit, len(databases))
+				return databases[opts.Offset:end], len(databases), nil, nil
+			},
+		}
+	}
+
+	t.Run("should only record fingerprints on the first call", func(t *testing.T) {
+		data, collection := newGraphs()
+		var reads []string
+		feed := newPermissionGraphFeed(newMockClient(data, collection, &reads), databaseFilter{})
+
+		events, state, _, err := feed.ListEvents(ctx, nil, &pagination.StreamToken{})
+		require.NoError(t, err)
+		require.Empty(t, events)
+		require.Equal(t, []string{"1", "2"}, reads)
+
+		cursor := &permissionGraphCursor{}
+		require.NoError(t, json.Unmarshal([]byte(state.Cursor), cursor))
+		require.Equal(t, 10, cursor.Data)
+		require.Equal(t, 4, cursor.Collection)
+		require.Len(t, cursor.Databases, 2)
+		require.Len(t, cursor.Collections, 2)
+	})
+
+	t.Run("should only read the first database while the data revision is unchanged", func(t *testing.T) {
+		data, collection := newGraphs()
+		var reads []string
+		feed := newPermissionGraphFeed(newMockClient(data, collection, &reads), databaseFilter{})
+
+		_, state, _, err := feed.ListEvents(ctx, nil, &pagination.StreamToken{})
+		require.NoError(t, err)
+
+		reads = nil
+		events, next, _, err := feed.ListEvents(ctx, nil, &pagination.StreamToken{Cursor: state.Cursor})
+		require.NoError(t, err)
+		require.Empty(t, events)
+		require.Equal(t, []string{"1"}, reads)
+		require.JSONEq(t, state.Cursor, next.Cursor)
+	})
+
+	t.Run("should only report the databases whose permissions changed", func(t *testing.T) {
+		data, collection := newGraphs()
+		var reads []string
+		feed := newPermissionGraphFeed(newMockClient(data, collection, &reads), databaseFilter{})
+
+		_, state, _, err := feed.ListEvents(ctx, nil, &pagination.StreamToken{})
+		require.NoError(t, err)
+
+		data.Revision = 11
+		data.Groups["1"]["2"] = &client.GroupPermission{ViewData: client.PermissionLevel("unrestricted"), CreateQueries: client.PermissionLevel("query-builder")}
+
+		events, _, _, err := feed.ListEvents(ctx, nil, &pagination.StreamToken{Cursor: state.Cursor})
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		require.Equal(t, databaseResourceType.Id, events[0].GetResourceChangeEvent().GetResourceId().GetResourceType())
+		require.Equal(t, "2", events[0].GetResourceChangeEvent().GetResourceId().GetResource())
+	})
+
+	t.Run("should only report the collections whose permissions changed", func(t *testing.T) {
+		data, collection := newGraphs()
+		var reads []string
+		feed := newPermissionGraphFeed(newMockClient(data, collection, &reads), databaseFilter{})
+
+		_, state, _, err := feed.ListEvents(ctx, nil, &pagination.StreamToken{})
+		require.NoError(t, err)
+
+		collection.Revision = 5
+		collection.Groups["1"]["5"] = "read"
+
+		events, _, _, err := feed.ListEvents(ctx, nil, &pagination.StreamToken{Cursor: state.Cursor})
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		require.Equal(t, collectionResourceType.Id, events[0].GetResourceChangeEvent().GetResourceId().GetResourceType())
+		require.Equal(t, "5", events[0].GetResourceChangeEvent().GetResourceId().GetResource())
+	})
+
+	t.Run("should only record fingerprints after a cursor without them", func(t *testing.T) {
+		data, collection := newGraphs()
+		data.Revision = 11
+		var reads []string
+		feed := newPermissionGraphFeed(newMockClient(data, collection, &reads), databaseFilter{})
+
+		events, _, _, err := feed.ListEvents(ctx, nil, &pagination.StreamToken{Cursor: `{"data": 10, "collection": 3}`})
+		require.NoError(t, err)
+		require.Empty(t, events)
+	})
+
+	t.Run("should not read nor report the databases that the filter excludes", func(t *testing.T) {
+		data, collection := newGraphs()
+		var reads []string
+		feed := newPermissionGraphFeed(newMockClient(data, collection, &reads), databaseFilter{excludeIDs: []string{"1"}})
+
+		_, state, _, err := feed.ListEvents(ctx, nil, &pagination.StreamToken{})
+		require.NoError(t, err)
+		require.Equal(t, []string{"2"}, reads)
+
+		data.Revision = 11
+		data.Groups["1"]["1"] = &client.GroupPermission{ViewData: client.PermissionLevel("blocked")}
+
+		events, _, _, err := feed.ListEvents(ctx, nil, &pagination.StreamToken{Cursor: state.Cursor})
+		require.NoError(t, err)
+		require.Empty(t, events)
+	})
+
+	t.Run("should page through the databases", func(t *testing.T) {
+		data, collection := newGraphs()
+		var reads []string
+		mockClient := newMockClient(data, collection, &reads)
+		var offsets []int
+		mockClient.ListDatabasesFunc = func(ctx context.Context, opts client.DatabaseListOptions) ([]*client.Database, int, *v2.RateLimitDescription, error) {
+			require.Equal(t, resourcePageSize, opts.Limit)
+			offsets = append(offsets, opts.Offset)
+			databases := make([]*client.Database, 0, opts.Limit)
+			for id := opts.Offset + 1; id <= min(opts.Offset+opts.Limit, 150); id++ {
+				databases = append(databases, &client.Database{ID: id})
+			}
+			return databases, 150, nil, nil
+		}
+		feed := newPermissionGraphFeed(mockClient, databaseFilter{})
+
+		_, state, _, err := feed.ListEvents(ctx, nil, &pagination.StreamToken{})
+		require.NoError(t, err)
+		require.Equal(t, []int{0, resourcePageSize}, offsets)
+
+		cursor := &permissionGraphCursor{}
+		require.NoError(t, json.Unmarshal([]byte(state.Cursor), cursor))
+		require.Len(t, cursor.Databases, 150)
+	})
+}
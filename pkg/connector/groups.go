@@ -0,0 +1,380 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/conductorone/baton-metabase-v056/pkg/client"
+	baseConnector "github.com/conductorone/baton-metabase/pkg/connector"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+// groupRoleDisplayNames names the group entitlements in their display name and description.
+var groupRoleDisplayNames = map[string]string{
+	baseConnector.MemberPermission:  "Member",
+	baseConnector.ManagerPermission: "Manager",
+}
+
+// groupBuilder syncs permission groups with the resource type and entitlement IDs of the base connector.
+type groupBuilder struct {
+	client client.ClientService
+	cache  *syncCache
+}
+
+// membershipsCacheKey keeps the memberships of every group, Metabase only listing the memberships of all groups at
+// once.
+const membershipsCacheKey = "memberships"
+
+// fetchMemberships returns the memberships of every group keyed by group ID, listed once per sync. The service
+// users of API keys are left out: they are not synced as users, and their memberships are synced on the keys.
+func fetchMemberships(ctx context.Context, cache *syncCache, cl client.ClientService, syncID string, ann *annotations.Annotations) (map[int][]*client.Membership, error) {
+	return fetchCached(cache, syncID, membershipsCacheKey, func() (map[int][]*client.Membership, error) {
+		memberships, rateLimitDesc, err := cl.ListMemberships(ctx)
+		if rateLimitDesc != nil {
+			ann.WithRateLimiting(rateLimitDesc)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list memberships: %w", err)
+		}
+
+		apiKeys, rateLimitDesc, err := cl.ListAPIKeys(ctx)
+		if rateLimitDesc != nil {
+			ann.WithRateLimiting(rateLimitDesc)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list API keys: %w", err)
+		}
+
+		serviceUsers := make(map[int]bool, len(apiKeys))
+		for _, apiKey := range apiKeys {
+			serviceUsers[apiKey.UserID] = true
+		}
+
+		members := make(map[int][]*client.Membership)
+		for _, userMemberships := range memberships {
+			for _, membership := range userMemberships {
+				if serviceUsers[membership.UserID] {
+					continue
+				}
+				members[membership.GroupID] = append(members[membership.GroupID], membership)
+			}
+		}
+		return members, nil
+	})
+}
+
+func (g *groupBuilder) ResourceType(_ context.Context) *v2.ResourceType {
+	return baseConnector.GroupResourceType
+}
+
+func (g *groupBuilder) List(ctx context.Context, _ *v2.ResourceId, _ resourceSdk.SyncOpAttrs) ([]*v2.Resource, *resourceSdk.SyncOpResults, error) {
+	ann := annotations.New()
+
+	groups, rateLimitDesc, err := g.client.ListGroups(ctx)
+	if rateLimitDesc != nil {
+		ann.WithRateLimiting(rateLimitDesc)
+	}
+	if err != nil {
+		return nil, &resourceSdk.SyncOpResults{Annotations: ann}, fmt.Errorf("failed to list groups: %w", err)
+	}
+
+	outResources := make([]*v2.Resource, 0, len(groups))
+	for _, group := range groups {
+		res, err := parseIntoGroupResource(group)
+		if err != nil {
+			return nil, &resourceSdk.SyncOpResults{Annotations: ann}, err
+		}
+		outResources = append(outResources, res)
+	}
+
+	return outResources, &resourceSdk.SyncOpResults{Annotations: ann}, nil
+}
+
+// Entitlements returns the member entitlement, plus the manager entitlement on paid plans, which are the only
+// ones with group managers.
+func (g *groupBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ resourceSdk.SyncOpAttrs) ([]*v2.Entitlement, *resourceSdk.SyncOpResults, error) {
+	roles := []string{baseConnector.MemberPermission}
+	if g.client.IsPaidPlan() {
+		roles = append(roles, baseConnector.ManagerPermission)
+	}
+
+	rv := make([]*v2.Entitlement, 0, len(roles))
+	for _, role := range roles {
+		displayRole := groupRoleDisplayNames[role]
+		rv = append(rv, entitlement.NewAssignmentEntitlement(resource, role,
+			entitlement.WithGrantableTo(baseConnector.UserResourceType),
+			entitlement.WithDisplayName(fmt.Sprintf("%s %s", resource.DisplayName, displayRole)),
+			entitlement.WithDescription(fmt.Sprintf("Is a %s of %s group in Metabase", displayRole, resource.DisplayName)),
+		))
+	}
+
+	return rv, nil, nil
+}
+
+// Grants returns the memberships of the group. Every member gets a member grant, and group managers get a manager
+// grant as well.
+func (g *groupBuilder) Grants(ctx context.Context, resource *v2.Resource, attrs resourceSdk.SyncOpAttrs) ([]*v2.Grant, *resourceSdk.SyncOpResults, error) {
+	groupID, err := strconv.Atoi(resource.Id.Resource)
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-metabase-v056: invalid group ID %s: %w", resource.Id.Resource, err)
+	}
+
+	ann := annotations.New()
+
+	members, err := fetchMemberships(ctx, g.cache, g.client, attrs.SyncID, &ann)
+	if err != nil {
+		return nil, &resourceSdk.SyncOpResults{Annotations: ann}, err
+	}
+
+	grants := make([]*v2.Grant, 0, len(members[groupID]))
+	for _, membership := range members[groupID] {
+		userID := &v2.ResourceId{
+			ResourceType: baseConnector.UserResourceType.Id,
+			Resource:     strconv.Itoa(membership.UserID),
+		}
+
+		grants = append(grants, grant.NewGrant(resource, baseConnector.MemberPermission, userID))
+		if membership.IsGroupManager {
+			grants = append(grants, grant.NewGrant(resource, baseConnector.ManagerPermission, userID))
+		}
+	}
+
+	return grants, &resourceSdk.SyncOpResults{Annotations: ann}, nil
+}
+
+// Grant adds the user to the group. The member and manager entitlements are granted independently: granting
+// manager to a member promotes the existing membership rather than reporting it as already granted.
+func (g *groupBuilder) Grant(ctx context.Context, principal *v2.Resource, ent *v2.Entitlement) (annotations.Annotations, error) {
+	if principal.Id.ResourceType != baseConnector.UserResourceType.Id {
+		return nil, fmt.Errorf("baton-metabase-v056: only users can be granted group membership, got %s", principal.Id.ResourceType)
+	}
+
+	isManager, err := isManagerEntitlement(ent)
+	if err != nil {
+		return nil, err
+	}
+
+	groupID, err := strconv.Atoi(ent.Resource.Id.Resource)
+	if err != nil {
+		return nil, fmt.Errorf("baton-metabase-v056: invalid group ID %s: %w", ent.Resource.Id.Resource, err)
+	}
+
+	userID, err := strconv.Atoi(principal.Id.Resource)
+	if err != nil {
+		return nil, fmt.Errorf("baton-metabase-v056: invalid user ID %s: %w", principal.Id.Resource, err)
+	}
+
+	ann := annotations.New()
+
+	membership, rateLimitDesc, err := g.findMembership(ctx, principal.Id.Resource, groupID)
+	if rateLimitDesc != nil {
+		ann.WithRateLimiting(rateLimitDesc)
+	}
+	if err != nil {
+		return ann, err
+	}
+
+	switch {
+	case membership == nil:
+		rateLimitDesc, err = g.client.AddUserToGroup(ctx, &client.Membership{
+			GroupID:        groupID,
+			UserID:         userID,
+			IsGroupManager: isManager,
+		})
+		if rateLimitDesc != nil {
+			ann.WithRateLimiting(rateLimitDesc)
+		}
+		if err != nil {
+			return ann, fmt.Errorf("failed to add user %d to group %d: %w", userID, groupID, err)
+		}
+
+	case isManager && !membership.IsGroupManager:
+		rateLimitDesc, err = g.client.SetGroupManager(ctx, strconv.Itoa(membership.MembershipID), true)
+		if rateLimitDesc != nil {
+			ann.WithRateLimiting(rateLimitDesc)
+		}
+		if err != nil {
+			return ann, fmt.Errorf("failed to promote user %d to manager of group %d: %w", userID, groupID, err)
+		}
+
+	default:
+		ann.Append(&v2.GrantAlreadyExists{})
+	}
+
+	return ann, nil
+}
+
+// Revoke demotes a manager to a plain member, or removes a member from the group. Since Metabase managers are
+// members too, the member entitlement of a manager cannot be revoked on its own: the manager entitlement must be
+// revoked first, so that revoking one entitlement never takes away the other. The memberships of API keys are
+// synced on the keys but cannot be revoked, Metabase tying each key to its group.
+func (g *groupBuilder) Revoke(ctx context.Context, grant *v2.Grant) (annotations.Annotations, error) {
+	if grant.Principal.Id.ResourceType != baseConnector.UserResourceType.Id {
+		return nil, fmt.Errorf("baton-metabase-v056: only the group membership of users can be revoked, got %s", grant.Principal.Id.ResourceType)
+	}
+
+	isManager, err := isManagerEntitlement(grant.Entitlement)
+	if err != nil {
+		return nil, err
+	}
+
+	groupID, err := strconv.Atoi(grant.Entitlement.Resource.Id.Resource)
+	if err != nil {
+		return nil, fmt.Errorf("baton-metabase-v056: invalid group ID %s: %w", grant.Entitlement.Resource.Id.Resource, err)
+	}
+
+	userID := grant.Principal.Id.Resource
+
+	ann := annotations.New()
+
+	membership, rateLimitDesc, err := g.findMembership(ctx, userID, groupID)
+	if rateLimitDesc != nil {
+		ann.WithRateLimiting(rateLimitDesc)
+	}
+	if err != nil {
+		return ann, err
+	}
+
+	switch {
+	case membership == nil, isManager && !membership.IsGroupManager:
+		ann.Append(&v2.GrantAlreadyRevoked{})
+
+	case isManager:
+		rateLimitDesc, err = g.client.SetGroupManager(ctx, strconv.Itoa(membership.MembershipID), false)
+		if rateLimitDesc != nil {
+			ann.WithRateLimiting(rateLimitDesc)
+		}
+		if err != nil {
+			return ann, fmt.Errorf("failed to demote user %s from manager of group %d: %w", userID, groupID, err)
+		}
+
+	case membership.IsGroupManager:
+		return ann, fmt.Errorf("baton-metabase-v056: user %s manages group %d, revoke the manager entitlement before the member one", userID, groupID)
+
+	default:
+		rateLimitDesc, err = g.client.RemoveUserFromGroup(ctx, strconv.Itoa(membership.MembershipID))
+		if rateLimitDesc != nil {
+			ann.WithRateLimiting(rateLimitDesc)
+		}
+		if err != nil {
+			return ann, fmt.Errorf("failed to remove user %s from group %d: %w", userID, groupID, err)
+		}
+	}
+
+	return ann, nil
+}
+
+// Create creates a group named after the display name of the resource.
+func (g *groupBuilder) Create(ctx context.Context, resource *v2.Resource) (*v2.Resource, annotations.Annotations, error) {
+	name := strings.TrimSpace(resource.GetDisplayName())
+	if name == "" {
+		return nil, nil, fmt.Errorf("baton-metabase-v056: a group name is required")
+	}
+
+	ann := annotations.New()
+
+	group, rateLimitDesc, err := g.client.CreateGroup(ctx, name)
+	if rateLimitDesc != nil {
+		ann.WithRateLimiting(rateLimitDesc)
+	}
+	if err != nil {
+		return nil, ann, err
+	}
+
+	groupResource, err := parseIntoGroupResource(group)
+	if err != nil {
+		return nil, ann, err
+	}
+
+	return groupResource, ann, nil
+}
+
+// Delete deletes the group, which also removes its memberships and permissions. The groups that Metabase
+// creates itself cannot be deleted.
+func (g *groupBuilder) Delete(ctx context.Context, resourceId *v2.ResourceId, _ *v2.ResourceId) (annotations.Annotations, error) {
+	if resourceId.ResourceType != baseConnector.GroupResourceType.Id {
+		return nil, fmt.Errorf("baton-metabase-v056: only groups can be deleted, got %s", resourceId.ResourceType)
+	}
+
+	if err := checkGroupEditable(resourceId.Resource); err != nil {
+		return nil, err
+	}
+
+	ann := annotations.New()
+
+	rateLimitDesc, err := g.client.DeleteGroup(ctx, resourceId.Resource)
+	if rateLimitDesc != nil {
+		ann.WithRateLimiting(rateLimitDesc)
+	}
+	if err != nil {
+		return ann, err
+	}
+
+	return ann, nil
+}
+
+// findMembership returns the membership of the user in the group, or nil when the user is not a member.
+func (g *groupBuilder) findMembership(ctx context.Context, userID string, groupID int) (*client.Membership, *v2.RateLimitDescription, error) {
+	memberships, rateLimitDesc, err := g.client.ListMemberships(ctx)
+	if err != nil {
+		return nil, rateLimitDesc, fmt.Errorf("failed to list memberships: %w", err)
+	}
+
+	for _, membership := range memberships[userID] {
+		if membership.GroupID == groupID {
+			return membership, rateLimitDesc, nil
+		}
+	}
+
+	return nil, rateLimitDesc, nil
+}
+
+// isManagerEntitlement tells whether the entitlement is the manager or the member entitlement of a group.
+func isManagerEntitlement(ent *v2.Entitlement) (bool, error) {
+	switch permissionFromEntitlement(ent) {
+	case baseConnector.MemberPermission:
+		return false, nil
+	case baseConnector.ManagerPermission:
+		return true, nil
+	default:
+		return false, fmt.Errorf("baton-metabase-v056: unsupported group entitlement %s", ent.Id)
+	}
+}
+
+// checkGroupEditable returns an error for the "All Users" and "Administrators" groups, which Metabase relies on.
+func checkGroupEditable(groupID string) error {
+	switch groupID {
+	case strconv.Itoa(client.AllUsersGroupID):
+		return fmt.Errorf("baton-metabase-v056: the built-in All Users group (ID %s) cannot be deleted or renamed", groupID)
+	case strconv.Itoa(client.AdministratorsGroupID):
+		return fmt.Errorf("baton-metabase-v056: the built-in Administrators group (ID %s) cannot be deleted or renamed", groupID)
+	}
+	return nil
+}
+
+func parseIntoGroupResource(group *client.Group) (*v2.Resource, error) {
+	profile := map[string]interface{}{
+		"name":         group.Name,
+		"member_count": group.MemberCount,
+	}
+
+	return resourceSdk.NewGroupResource(
+		group.Name,
+		baseConnector.GroupResourceType,
+		group.ID,
+		[]resourceSdk.GroupTraitOption{resourceSdk.WithGroupProfile(profile)},
+	)
+}
+
+func newGroupBuilder(client client.ClientService, cache *syncCache) *groupBuilder {
+	return &groupBuilder{
+		client: client,
+		cache:  cache,
+	}
+}
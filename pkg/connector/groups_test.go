@@ -0,0 +1,398 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/conductorone/baton-metabase-v056/pkg/client"
+	baseConnector "github.com/conductorone/baton-metabase/pkg/connector"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func newTestGroupBuilder() (*groupBuilder, *client.MockService) {
+	mockClient := &client.MockService{}
+	builder := newGroupBuilder(mockClient, newSyncCache())
+	return builder, mockClient
+}
+
+func testGroupMemberships(ctx context.Context) (map[string][]*client.Membership, *v2.RateLimitDescription, error) {
+	return map[string][]*client.Membership{
+		"7": {
+			{MembershipID: 11, GroupID: 3, UserID: 7},
+			{MembershipID: 12, GroupID: 5, UserID: 7, IsGroupManager: true},
+		},
+	}, nil, nil
+}
+
+func TestGroupEntitlements(t *testing.T) {
+	ctx := context.Background()
+	groupResource := &v2.Resource{
+		Id:          &v2.ResourceId{ResourceType: baseConnector.GroupResourceType.Id, Resource: "3"},
+		DisplayName: "Analysts",
+	}
+
+	t.Run("should only offer membership on free plans", func(t *testing.T) {
+		builder, _ := newTestGroupBuilder()
+
+		entitlements, _, err := builder.Entitlements(ctx, groupResource, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.NoError(t, err)
+		require.Len(t, entitlements, 1)
+		require.Equal(t, "Analysts Member", entitlements[0].DisplayName)
+	})
+
+	t.Run("should offer management on paid plans", func(t *testing.T) {
+		builder, mockClient := newTestGroupBuilder()
+		mockClient.IsPaidPlanFunc = func() bool { return true }
+
+		entitlements, _, err := builder.Entitlements(ctx, groupResource, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.NoError(t, err)
+		require.Len(t, entitlements, 2)
+		require.Equal(t, "group:3:manager", entitlements[1].Id)
+	})
+}
+
+func TestGroupGrants(t *testing.T) {
+	ctx := context.Background()
+	groupResource := &v2.Resource{Id: &v2.ResourceId{ResourceType: baseConnector.GroupResourceType.Id, Resource: "3"}}
+
+	t.Run("should grant the member role of every member and the manager role of managers", func(t *testing.T) {
+		builder, mockClient := newTestGroupBuilder()
+		mockClient.ListMembershipsFunc = func(ctx context.Context) (map[string][]*client.Membership, *v2.RateLimitDescription, error) {
+			return map[string][]*client.Membership{
+				"7": {
+					{MembershipID: 1, GroupID: 1, UserID: 7},
+					{MembershipID: 2, GroupID: 3, UserID: 7, IsGroupManager: true},
+				},
+				"8": {{MembershipID: 3, GroupID: 1, UserID: 8}},
+			}, nil, nil
+		}
+		mockClient.ListAPIKeysFunc = func(ctx context.Context) ([]*client.APIKey, *v2.RateLimitDescription, error) {
+			return nil, nil, nil
+		}
+
+		grants, _, err := builder.Grants(ctx, groupResource, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.NoError(t, err)
+		require.Len(t, grants, 2)
+		require.Equal(t, "group:3:member", grants[0].Entitlement.Id)
+		require.Equal(t, "group:3:manager", grants[1].Entitlement.Id)
+		require.Equal(t, baseConnector.UserResourceType.Id, grants[1].Principal.Id.ResourceType)
+		require.Equal(t, "7", grants[1].Principal.Id.Resource)
+	})
+
+	t.Run("should leave out the service users of API keys", func(t *testing.T) {
+		builder, mockClient := newTestGroupBuilder()
+		mockClient.ListMembershipsFunc = func(ctx context.Context) (map[string][]*client.Membership, *v2.RateLimitDescription, error) {
+			return map[string][]*client.Membership{
+				"7":  {{MembershipID: 1, GroupID: 3, UserID: 7}},
+				"13": {{MembershipID: 2, GroupID: 3, UserID: 13}},
+			}, nil, nil
+		}
+		mockClient.ListAPIKeysFunc = func(ctx context.Context) ([]*client.APIKey, *v2.RateLimitDescription, error) {
+			return []*client.APIKey{{ID: 1, UserID: 13}}, nil, nil
+		}
+
+		grants, _, err := builder.Grants(ctx, groupResource, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.NoError(t, err)
+		require.Len(t, grants, 1)
+		require.Equal(t, "7", grants[0].Principal.Id.Resource)
+	})
+
+	t.Run("should return error if ListMemberships fails", func(t *testing.T) {
+		builder, mockClient := newTestGroupBuilder()
+		mockClient.ListMembershipsFunc = func(ctx context.Context) (map[string][]*client.Membership, *v2.RateLimitDescription, error) {
+			return nil, nil, fmt.Errorf("API error")
+		}
+
+		_, _, err := builder.Grants(ctx, groupResource, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.Error(t, err)
+	})
+
+	t.Run("should list the memberships once per sync", func(t *testing.T) {
+		builder, mockClient := newTestGroupBuilder()
+		mockClient.ListGroupsFunc = func(ctx context.Context) ([]*client.Group, *v2.RateLimitDescription, error) {
+			return []*client.Group{{ID: 1, Name: "All Users"}, {ID: 3, Name: "Analysts"}}, nil, nil
+		}
+		reads := 0
+		mockClient.ListMembershipsFunc = func(ctx context.Context) (map[string][]*client.Membership, *v2.RateLimitDescription, error) {
+			reads++
+			return map[string][]*client.Membership{
+				"7": {{MembershipID: 1, GroupID: 1, UserID: 7}, {MembershipID: 2, GroupID: 3, UserID: 7}},
+			}, nil, nil
+		}
+		mockClient.ListAPIKeysFunc = func(ctx context.Context) ([]*client.APIKey, *v2.RateLimitDescription, error) {
+			return nil, nil, nil
+		}
+
+		sync := func(syncID string) {
+			groups, _, err := builder.List(ctx, nil, resourceSdk.SyncOpAttrs{SyncID: syncID})
+			require.NoError(t, err)
+			for _, group := range groups {
+				grants, _, err := builder.Grants(ctx, group, resourceSdk.SyncOpAttrs{SyncID: syncID})
+				require.NoError(t, err)
+				require.Len(t, grants, 1)
+			}
+		}
+
+		sync("sync-1")
+		require.Equal(t, 1, reads)
+		sync("sync-1")
+		require.Equal(t, 1, reads)
+		sync("sync-2")
+		require.Equal(t, 2, reads)
+	})
+
+	t.Run("should list the memberships for each group without a sync ID", func(t *testing.T) {
+		builder, mockClient := newTestGroupBuilder()
+		reads := 0
+		mockClient.ListMembershipsFunc = func(ctx context.Context) (map[string][]*client.Membership, *v2.RateLimitDescription, error) {
+			reads++
+			return nil, nil, nil
+		}
+		mockClient.ListAPIKeysFunc = func(ctx context.Context) ([]*client.APIKey, *v2.RateLimitDescription, error) {
+			return nil, nil, nil
+		}
+
+		for range 2 {
+			_, _, err := builder.Grants(ctx, groupResource, resourceSdk.SyncOpAttrs{})
+			require.NoError(t, err)
+		}
+		require.Equal(t, 2, reads)
+	})
+}
+
+func TestGroupGrant(t *testing.T) {
+	ctx := context.Background()
+	userResource := &v2.Resource{Id: &v2.ResourceId{ResourceType: baseConnector.UserResourceType.Id, Resource: "7"}}
+
+	newEntitlement := func(groupID string, role string) *v2.Entitlement {
+		return &v2.Entitlement{
+			Id: fmt.Sprintf("group:%s:%s", groupID, role),
+			Resource: &v2.Resource{
+				Id: &v2.ResourceId{ResourceType: baseConnector.GroupResourceType.Id, Resource: groupID},
+			},
+		}
+	}
+
+	t.Run("should add the user to the group as manager", func(t *testing.T) {
+		builder, mockClient := newTestGroupBuilder()
+		mockClient.ListMembershipsFunc = testGroupMemberships
+		var added *client.Membership
+		mockClient.AddUserToGroupFunc = func(ctx context.Context, membership *client.Membership) (*v2.RateLimitDescription, error) {
+			added = membership
+			return nil, nil
+		}
+
+		_, err := builder.Grant(ctx, userResource, newEntitlement("4", baseConnector.ManagerPermission))
+		require.NoError(t, err)
+		require.Equal(t, &client.Membership{GroupID: 4, UserID: 7, IsGroupManager: true}, added)
+	})
+
+	t.Run("should report an existing membership", func(t *testing.T) {
+		builder, mockClient := newTestGroupBuilder()
+		mockClient.ListMembershipsFunc = testGroupMemberships
+
+		ann, err := builder.Grant(ctx, userResource, newEntitlement("3", baseConnector.MemberPermission))
+		require.NoError(t, err)
+		require.True(t, ann.Contains(&v2.GrantAlreadyExists{}))
+
+		ann, err = builder.Grant(ctx, userResource, newEntitlement("5", baseConnector.MemberPermission))
+		require.NoError(t, err)
+		require.True(t, ann.Contains(&v2.GrantAlreadyExists{}))
+	})
+
+	t.Run("should promote a member to manager", func(t *testing.T) {
+		builder, mockClient := newTestGroupBuilder()
+		mockClient.ListMembershipsFunc = testGroupMemberships
+		var promoted string
+		mockClient.SetGroupManagerFunc = func(ctx context.Context, membershipID string, isGroupManager bool) (*v2.RateLimitDescription, error) {
+			require.True(t, isGroupManager)
+			promoted = membershipID
+			return nil, nil
+		}
+
+		ann, err := builder.Grant(ctx, userResource, newEntitlement("3", baseConnector.ManagerPermission))
+		require.NoError(t, err)
+		require.False(t, ann.Contains(&v2.GrantAlreadyExists{}))
+		require.Equal(t, "11", promoted)
+	})
+
+	t.Run("should reject other principals", func(t *testing.T) {
+		builder, _ := newTestGroupBuilder()
+
+		_, err := builder.Grant(ctx, &v2.Resource{Id: &v2.ResourceId{ResourceType: "api_key", Resource: "1"}}, newEntitlement("3", baseConnector.MemberPermission))
+		require.Error(t, err)
+	})
+}
+
+func TestGroupRevoke(t *testing.T) {
+	ctx := context.Background()
+
+	newGrant := func(groupID string, role string) *v2.Grant {
+		return &v2.Grant{
+			Entitlement: &v2.Entitlement{
+				Id:       fmt.Sprintf("group:%s:%s", groupID, role),
+				Resource: &v2.Resource{Id: &v2.ResourceId{ResourceType: baseConnector.GroupResourceType.Id, Resource: groupID}},
+			},
+			Principal: &v2.Resource{Id: &v2.ResourceId{ResourceType: baseConnector.UserResourceType.Id, Resource: "7"}},
+		}
+	}
+
+	t.Run("should remove the membership", func(t *testing.T) {
+		builder, mockClient := newTestGroupBuilder()
+		mockClient.ListMembershipsFunc = testGroupMemberships
+		var removed string
+		mockClient.RemoveUserFromGroupFunc = func(ctx context.Context, membershipID string) (*v2.RateLimitDescription, error) {
+			removed = membershipID
+			return nil, nil
+		}
+
+		_, err := builder.Revoke(ctx, newGrant("3", baseConnector.MemberPermission))
+		require.NoError(t, err)
+		require.Equal(t, "11", removed)
+	})
+
+	t.Run("should report a missing membership", func(t *testing.T) {
+		builder, mockClient := newTestGroupBuilder()
+		mockClient.ListMembershipsFunc = testGroupMemberships
+
+		ann, err := builder.Revoke(ctx, newGrant("4", baseConnector.MemberPermission))
+		require.NoError(t, err)
+		require.True(t, ann.Contains(&v2.GrantAlreadyRevoked{}))
+
+		ann, err = builder.Revoke(ctx, newGrant("3", baseConnector.ManagerPermission))
+		require.NoError(t, err)
+		require.True(t, ann.Contains(&v2.GrantAlreadyRevoked{}))
+	})
+
+	t.Run("should demote a manager and keep the membership", func(t *testing.T) {
+		builder, mockClient := newTestGroupBuilder()
+		mockClient.ListMembershipsFunc = testGroupMemberships
+		var demoted string
+		mockClient.SetGroupManagerFunc = func(ctx context.Context, membershipID string, isGroupManager bool) (*v2.RateLimitDescription, error) {
+			require.False(t, isGroupManager)
+			demoted = membershipID
+			return nil, nil
+		}
+
+		_, err := builder.Revoke(ctx, newGrant("5", baseConnector.ManagerPermission))
+		require.NoError(t, err)
+		require.Equal(t, "12", demoted)
+	})
+
+	t.Run("should not remove a manager when revoking the member role", func(t *testing.T) {
+		builder, mockClient := newTestGroupBuilder()
+		mockClient.ListMembershipsFunc = testGroupMemberships
+
+		_, err := builder.Revoke(ctx, newGrant("5", baseConnector.MemberPermission))
+		require.ErrorContains(t, err, "revoke the manager entitlement")
+	})
+
+	t.Run("should reject API keys sharing their ID with a member", func(t *testing.T) {
+		builder, mockClient := newTestGroupBuilder()
+		mockClient.ListMembershipsFunc = testGroupMemberships
+		mockClient.RemoveUserFromGroupFunc = func(ctx context.Context, membershipID string) (*v2.RateLimitDescription, error) {
+			require.Fail(t, "the membership of user 7 must not be removed")
+			return nil, nil
+		}
+
+		apiKeyGrant := newGrant("3", baseConnector.MemberPermission)
+		apiKeyGrant.Principal = &v2.Resource{Id: &v2.ResourceId{ResourceType: apiKeyResourceType.Id, Resource: "7"}}
+
+		ann, err := builder.Revoke(ctx, apiKeyGrant)
+		require.Error(t, err)
+		require.False(t, ann.Contains(&v2.GrantAlreadyRevoked{}))
+	})
+}
+
+func TestGroupCreate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should create the group", func(t *testing.T) {
+		builder, mockClient := newTestGroupBuilder()
+		mockClient.CreateGroupFunc = func(ctx context.Context, name string) (*client.Group, *v2.RateLimitDescription, error) {
+			require.Equal(t, "Analysts", name)
+			return &client.Group{ID: 5, Name: name}, nil, nil
+		}
+
+		resource, _, err := builder.Create(ctx, &v2.Resource{DisplayName: " Analysts "})
+		require.NoError(t, err)
+		require.Equal(t, "5", resource.Id.Resource)
+		require.Equal(t, baseConnector.GroupResourceType.Id, resource.Id.ResourceType)
+	})
+
+	t.Run("should require a name", func(t *testing.T) {
+		builder, _ := newTestGroupBuilder()
+
+		_, _, err := builder.Create(ctx, &v2.Resource{})
+		require.Error(t, err)
+	})
+}
+
+func TestGroupDelete(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should delete the group", func(t *testing.T) {
+		builder, mockClient := newTestGroupBuilder()
+		var deleted string
+		mockClient.DeleteGroupFunc = func(ctx context.Context, groupID string) (*v2.RateLimitDescription, error) {
+			deleted = groupID
+			return nil, nil
+		}
+
+		_, err := builder.Delete(ctx, &v2.ResourceId{ResourceType: baseConnector.GroupResourceType.Id, Resource: "5"}, nil)
+		require.NoError(t, err)
+		require.Equal(t, "5", deleted)
+	})
+
+	t.Run("should protect the built-in groups", func(t *testing.T) {
+		builder, _ := newTestGroupBuilder()
+
+		for _, groupID := range []string{"1", "2"} {
+			_, err := builder.Delete(ctx, &v2.ResourceId{ResourceType: baseConnector.GroupResourceType.Id, Resource: groupID}, nil)
+			require.ErrorContains(t, err, "cannot be deleted")
+		}
+	})
+}
+
+func TestRenameGroup(t *testing.T) {
+	ctx := context.Background()
+
+	newArgs := func(groupID string, name string) *structpb.Struct {
+		return &structpb.Struct{Fields: map[string]*structpb.Value{
+			"groupId": structpb.NewStringValue(groupID),
+			"name":    structpb.NewStringValue(name),
+		}}
+	}
+
+	t.Run("should rename the group", func(t *testing.T) {
+		mockClient := newTestClient()
+		mockClient.RenameGroupFunc = func(ctx context.Context, groupID string, name string) (*client.Group, *v2.RateLimitDescription, error) {
+			require.Equal(t, "5", groupID)
+			require.Equal(t, "Data Team", name)
+			return &client.Group{ID: 5, Name: name}, nil, nil
+		}
+		c := &Connector{v056Client: mockClient}
+
+		resp, _, err := c.RenameGroup(ctx, newArgs("5", "Data Team"))
+		require.NoError(t, err)
+		require.True(t, resp.Fields["success"].GetBoolValue())
+	})
+
+	t.Run("should protect the built-in groups", func(t *testing.T) {
+		c := &Connector{v056Client: newTestClient()}
+
+		_, _, err := c.RenameGroup(ctx, newArgs("2", "Admins"))
+		require.Error(t, err)
+	})
+
+	t.Run("should require a name", func(t *testing.T) {
+		c := &Connector{v056Client: newTestClient()}
+
+		_, _, err := c.RenameGroup(ctx, newArgs("5", " "))
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,145 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/conductorone/baton-metabase-v056/pkg/client"
+	baseConnector "github.com/conductorone/baton-metabase/pkg/connector"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+const (
+	instanceID          = "metabase"
+	instanceDisplayName = "Metabase"
+
+	// adminPermission is granted to the superusers, which have every right in Metabase. Metabase keeps the superusers
+	// and the members of the Administrators group in step, so the grants are read from the members of the group.
+	adminPermission = "admin"
+)
+
+// instanceBuilder syncs the Metabase instance itself, which holds the instance-wide rights of users.
+type instanceBuilder struct {
+	client client.ClientService
+	cache  *syncCache
+}
+
+func (i *instanceBuilder) ResourceType(_ context.Context) *v2.ResourceType {
+	return instanceResourceType
+}
+
+func (i *instanceBuilder) List(_ context.Context, _ *v2.ResourceId, _ resourceSdk.SyncOpAttrs) ([]*v2.Resource, *resourceSdk.SyncOpResults, error) {
+	res, err := resourceSdk.NewResource(instanceDisplayName, instanceResourceType, instanceID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return []*v2.Resource{res}, nil, nil
+}
+
+func (i *instanceBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ resourceSdk.SyncOpAttrs) ([]*v2.Entitlement, *resourceSdk.SyncOpResults, error) {
+	return []*v2.Entitlement{
+		entitlement.NewPermissionEntitlement(resource, adminPermission,
+			entitlement.WithGrantableTo(baseConnector.UserResourceType),
+			entitlement.WithDisplayName(fmt.Sprintf("%s Admin", resource.DisplayName)),
+			entitlement.WithDescription(fmt.Sprintf("Grants superuser (Admin) rights on the %s instance", resource.DisplayName)),
+		),
+	}, nil, nil
+}
+
+func (i *instanceBuilder) Grants(ctx context.Context, resource *v2.Resource, attrs resourceSdk.SyncOpAttrs) ([]*v2.Grant, *resourceSdk.SyncOpResults, error) {
+	ann := annotations.New()
+
+	members, err := fetchMemberships(ctx, i.cache, i.client, attrs.SyncID, &ann)
+	if err != nil {
+		return nil, &resourceSdk.SyncOpResults{Annotations: ann}, err
+	}
+
+	var grants []*v2.Grant
+	for _, membership := range members[client.AdministratorsGroupID] {
+		userResource := &v2.Resource{
+			Id: &v2.ResourceId{
+				ResourceType: baseConnector.UserResourceType.Id,
+				Resource:     strconv.Itoa(membership.UserID),
+			},
+		}
+		grants = append(grants, grant.NewGrant(resource, adminPermission, userResource))
+	}
+
+	return grants, &resourceSdk.SyncOpResults{Annotations: ann}, nil
+}
+
+func (i *instanceBuilder) Grant(ctx context.Context, principal *v2.Resource, _ *v2.Entitlement) (annotations.Annotations, error) {
+	if principal.Id.ResourceType != baseConnector.UserResourceType.Id {
+		return nil, fmt.Errorf("baton-metabase-v056: only users can be granted admin rights, got %s", principal.Id.ResourceType)
+	}
+
+	ann, changed, err := i.setSuperuser(ctx, principal.Id.Resource, true)
+	if err != nil {
+		return ann, fmt.Errorf("failed to grant admin rights to user %s: %w", principal.Id.Resource, err)
+	}
+
+	if !changed {
+		ann.Append(&v2.GrantAlreadyExists{})
+	}
+
+	return ann, nil
+}
+
+func (i *instanceBuilder) Revoke(ctx context.Context, g *v2.Grant) (annotations.Annotations, error) {
+	principal := g.Principal
+	if principal.Id.ResourceType != baseConnector.UserResourceType.Id {
+		return nil, fmt.Errorf("baton-metabase-v056: only users can be revoked admin rights, got %s", principal.Id.ResourceType)
+	}
+
+	ann, changed, err := i.setSuperuser(ctx, principal.Id.Resource, false)
+	if err != nil {
+		return ann, fmt.Errorf("failed to revoke admin rights from user %s: %w", principal.Id.Resource, err)
+	}
+
+	if !changed {
+		ann.Append(&v2.GrantAlreadyRevoked{})
+	}
+
+	return ann, nil
+}
+
+// setSuperuser updates the superuser flag of the user, unless it already has the given value.
+// It returns whether the user was updated.
+func (i *instanceBuilder) setSuperuser(ctx context.Context, userID string, isSuperuser bool) (annotations.Annotations, bool, error) {
+	ann := annotations.New()
+
+	user, rateLimitDesc, err := i.client.GetUser(ctx, userID)
+	if rateLimitDesc != nil {
+		ann.WithRateLimiting(rateLimitDesc)
+	}
+	if err != nil {
+		return ann, false, err
+	}
+
+	if user.IsSuperuser == isSuperuser {
+		return ann, false, nil
+	}
+
+	_, rateLimitDesc, err = i.client.UpdateUser(ctx, userID, &client.UserUpdate{IsSuperuser: &isSuperuser})
+	if rateLimitDesc != nil {
+		ann.WithRateLimiting(rateLimitDesc)
+	}
+	if err != nil {
+		return ann, false, err
+	}
+
+	return ann, true, nil
+}
+
+func newInstanceBuilder(client client.ClientService, cache *syncCache) *instanceBuilder {
+	return &instanceBuilder{
+		client: client,
+		cache:  cache,
+	}
+}
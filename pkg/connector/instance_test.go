@@ -0,0 +1,165 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/conductorone/baton-metabase-v056/pkg/client"
+	baseConnector "github.com/conductorone/baton-metabase/pkg/connector"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestInstanceBuilder() (*instanceBuilder, *client.MockService) {
+	mockClient := &client.MockService{}
+	builder := newInstanceBuilder(mockClient, newSyncCache())
+	return builder, mockClient
+}
+
+func TestInstanceGrants(t *testing.T) {
+	ctx := context.Background()
+	instanceResource := &v2.Resource{
+		Id:          &v2.ResourceId{ResourceType: instanceResourceType.Id, Resource: instanceID},
+		DisplayName: instanceDisplayName,
+	}
+
+	t.Run("should grant admin to the members of the Administrators group", func(t *testing.T) {
+		builder, mockClient := newTestInstanceBuilder()
+		mockClient.ListMembershipsFunc = func(ctx context.Context) (map[string][]*client.Membership, *v2.RateLimitDescription, error) {
+			return map[string][]*client.Membership{
+				"1": {{GroupID: 1, UserID: 1}, {GroupID: client.AdministratorsGroupID, UserID: 1}},
+				"4": {{GroupID: 1, UserID: 4}},
+				"9": {{GroupID: client.AdministratorsGroupID, UserID: 9}},
+			}, nil, nil
+		}
+		mockClient.ListAPIKeysFunc = func(ctx context.Context) ([]*client.APIKey, *v2.RateLimitDescription, error) {
+			return []*client.APIKey{{ID: 3, UserID: 9}}, nil, nil
+		}
+		mockClient.ListUsersFunc = func(ctx context.Context, opts client.PageOptions) ([]*client.User, int, *v2.RateLimitDescription, error) {
+			t.Fatal("admin grants must not page through users")
+			return nil, 0, nil, nil
+		}
+
+		grants, results, err := builder.Grants(ctx, instanceResource, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.NoError(t, err)
+		require.Empty(t, results.NextPageToken)
+		require.Len(t, grants, 1)
+		require.Equal(t, "1", grants[0].Principal.Id.Resource)
+		require.Equal(t, baseConnector.UserResourceType.Id, grants[0].Principal.Id.ResourceType)
+	})
+
+	t.Run("should reuse the memberships listed for the groups", func(t *testing.T) {
+		cache := newSyncCache()
+		reads := 0
+		mockClient := &client.MockService{
+			ListMembershipsFunc: func(ctx context.Context) (map[string][]*client.Membership, *v2.RateLimitDescription, error) {
+				reads++
+				return map[string][]*client.Membership{"1": {{GroupID: client.AdministratorsGroupID, UserID: 1}}}, nil, nil
+			},
+			ListAPIKeysFunc: func(ctx context.Context) ([]*client.APIKey, *v2.RateLimitDescription, error) {
+				return nil, nil, nil
+			},
+		}
+		groupResource := &v2.Resource{Id: &v2.ResourceId{ResourceType: baseConnector.GroupResourceType.Id, Resource: "2"}}
+
+		_, _, err := newGroupBuilder(mockClient, cache).Grants(ctx, groupResource, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.NoError(t, err)
+		grants, _, err := newInstanceBuilder(mockClient, cache).Grants(ctx, instanceResource, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.NoError(t, err)
+		require.Len(t, grants, 1)
+		require.Equal(t, 1, reads)
+	})
+
+	t.Run("should return error if ListMemberships fails", func(t *testing.T) {
+		builder, mockClient := newTestInstanceBuilder()
+		mockClient.ListMembershipsFunc = func(ctx context.Context) (map[string][]*client.Membership, *v2.RateLimitDescription, error) {
+			return nil, nil, fmt.Errorf("API error")
+		}
+
+		_, _, err := builder.Grants(ctx, instanceResource, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.Error(t, err)
+	})
+}
+
+func TestInstanceGrant(t *testing.T) {
+	ctx := context.Background()
+	userResource := &v2.Resource{Id: &v2.ResourceId{ResourceType: baseConnector.UserResourceType.Id, Resource: "5"}}
+	adminEntitlement := &v2.Entitlement{Id: fmt.Sprintf("%s:%s:%s", instanceResourceType.Id, instanceID, adminPermission)}
+
+	t.Run("should set is_superuser on the user", func(t *testing.T) {
+		builder, mockClient := newTestInstanceBuilder()
+		mockClient.GetUserFunc = func(ctx context.Context, userID string) (*client.User, *v2.RateLimitDescription, error) {
+			return &client.User{ID: 5}, nil, nil
+		}
+
+		var update *client.UserUpdate
+		mockClient.UpdateUserFunc = func(ctx context.Context, userID string, u *client.UserUpdate) (*client.User, *v2.RateLimitDescription, error) {
+			require.Equal(t, "5", userID)
+			update = u
+			return &client.User{ID: 5, IsSuperuser: true}, nil, nil
+		}
+
+		ann, err := builder.Grant(ctx, userResource, adminEntitlement)
+		require.NoError(t, err)
+		require.False(t, ann.Contains(&v2.GrantAlreadyExists{}))
+		require.NotNil(t, update.IsSuperuser)
+		require.True(t, *update.IsSuperuser)
+	})
+
+	t.Run("should return GrantAlreadyExists if user is already a superuser", func(t *testing.T) {
+		builder, mockClient := newTestInstanceBuilder()
+		mockClient.GetUserFunc = func(ctx context.Context, userID string) (*client.User, *v2.RateLimitDescription, error) {
+			return &client.User{ID: 5, IsSuperuser: true}, nil, nil
+		}
+
+		ann, err := builder.Grant(ctx, userResource, adminEntitlement)
+		require.NoError(t, err)
+		require.True(t, ann.Contains(&v2.GrantAlreadyExists{}))
+	})
+
+	t.Run("should reject non-user principals", func(t *testing.T) {
+		builder, _ := newTestInstanceBuilder()
+		groupResource := &v2.Resource{Id: &v2.ResourceId{ResourceType: baseConnector.GroupResourceType.Id, Resource: "2"}}
+
+		_, err := builder.Grant(ctx, groupResource, adminEntitlement)
+		require.Error(t, err)
+	})
+}
+
+func TestInstanceRevoke(t *testing.T) {
+	ctx := context.Background()
+	adminGrant := &v2.Grant{
+		Entitlement: &v2.Entitlement{Id: fmt.Sprintf("%s:%s:%s", instanceResourceType.Id, instanceID, adminPermission)},
+		Principal:   &v2.Resource{Id: &v2.ResourceId{ResourceType: baseConnector.UserResourceType.Id, Resource: "5"}},
+	}
+
+	t.Run("should unset is_superuser on the user", func(t *testing.T) {
+		builder, mockClient := newTestInstanceBuilder()
+		mockClient.GetUserFunc = func(ctx context.Context, userID string) (*client.User, *v2.RateLimitDescription, error) {
+			return &client.User{ID: 5, IsSuperuser: true}, nil, nil
+		}
+
+		var update *client.UserUpdate
+		mockClient.UpdateUserFunc = func(ctx context.Context, userID string, u *client.UserUpdate) (*client.User, *v2.RateLimitDescription, error) {
+			update = u
+			return &client.User{ID: 5}, nil, nil
+		}
+
+		_, err := builder.Revoke(ctx, adminGrant)
+		require.NoError(t, err)
+		require.False(t, *update.IsSuperuser)
+	})
+
+	t.Run("should return GrantAlreadyRevoked if user is not a superuser", func(t *testing.T) {
+		builder, mockClient := newTestInstanceBuilder()
+		mockClient.GetUserFunc = func(ctx context.Context, userID string) (*client.User, *v2.RateLimitDescription, error) {
+			return &client.User{ID: 5}, nil, nil
+		}
+
+		ann, err := builder.Revoke(ctx, adminGrant)
+		require.NoError(t, err)
+		require.True(t, ann.Contains(&v2.GrantAlreadyRevoked{}))
+	})
+}
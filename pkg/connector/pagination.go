@@ -0,0 +1,44 @@
+package connector
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/conductorone/baton-metabase-v056/pkg/client"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+)
+
+const resourcePageSize = 100
+
+// getPageOptions and getNextPageToken are copies of the unexported helpers of the base connector, which pages the
+// users it syncs the same way. Keep them identical to the base ones, so that both connectors read page tokens alike.
+
+func getPageOptions(pToken *pagination.Token, pageSize int) (client.PageOptions, error) {
+	var offset int
+	if pToken != nil && pToken.Token != "" {
+		o, err := strconv.Atoi(pToken.Token)
+		if err != nil {
+			return client.PageOptions{}, fmt.Errorf("invalid page token: %w", err)
+		}
+		offset = o
+	}
+
+	var limit int
+	if pToken != nil && pToken.Size > 0 {
+		limit = pToken.Size
+	} else {
+		limit = pageSize
+	}
+
+	return client.PageOptions{
+		Limit:  limit,
+		Offset: offset,
+	}, nil
+}
+
+func getNextPageToken(offset, limit, total int) string {
+	if offset+limit < total {
+		return strconv.Itoa(offset + limit)
+	}
+	return ""
+}
@@ -0,0 +1,269 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/conductorone/baton-metabase-v056/pkg/client"
+	baseConnector "github.com/conductorone/baton-metabase/pkg/connector"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+)
+
+const (
+	queryBuilderPermission          = "query-builder"
+	queryBuilderAndNativePermission = "query-builder-and-native"
+
+	viewDataUnrestrictedPermission = "view-data-unrestricted"
+	viewDataLegacyPermission       = "view-data-legacy-no-self-service"
+	viewDataImpersonatedPermission = "view-data-impersonated"
+	viewDataSandboxedPermission    = "view-data-sandboxed"
+	downloadFullPermission         = "download-full"
+	downloadLimitedPermission      = "download-limited"
+	dataModelPermission            = "data-model-all"
+	detailsPermission              = "details-yes"
+
+	// maxGraphUpdateAttempts bounds how many times a permission graph is re-read and written
+	// again when Metabase reports that the revision changed in the meantime.
+	maxGraphUpdateAttempts = 3
+)
+
+// permissionDimension is one dimension of the data permission graph, e.g. view-data or download.
+type permissionDimension struct {
+	Name string
+	// Revoked is the value written back when a level of this dimension is revoked.
+	Revoked string
+	// RevokedPaidOnly dimensions cannot be revoked on free plans, which do not have the Revoked level.
+	RevokedPaidOnly bool
+	// DatabaseOnly dimensions cannot be set per schema or table.
+	DatabaseOnly bool
+	// Ranks orders the levels that give access: a level of higher rank includes the access of a lower one, and
+	// groups with it are granted both levels. Levels of the same rank, or without a rank, are alternatives.
+	Ranks map[string]int
+	get   func(p *client.GroupPermission) *client.PermissionValue
+	set   func(value string) *client.GroupPermission
+}
+
+// includes reports whether the current level of the dimension gives the access of level, either because it is
+// that level or because both are ranked and it outranks level.
+func (d *permissionDimension) includes(current string, level string) bool {
+	if current == level {
+		return true
+	}
+	rank, ok := d.Ranks[current]
+	levelRank, levelOk := d.Ranks[level]
+	return ok && levelOk && rank > levelRank
+}
+
+var (
+	viewDataDimension = &permissionDimension{
+		Name:    "view-data",
+		Revoked: "blocked",
+		// Free plans cannot block data access, which is removed by revoking the query permissions instead.
+		RevokedPaidOnly: true,
+		// The view data levels are alternatives: sandboxed and impersonated access restrict what unrestricted
+		// access shows rather than giving less of it.
+		get: func(p *client.GroupPermission) *client.PermissionValue { return p.ViewData },
+		set: func(value string) *client.GroupPermission {
+			return &client.GroupPermission{ViewData: client.PermissionLevel(value)}
+		},
+	}
+	createQueriesDimension = &permissionDimension{
+		Name:    "create-queries",
+		Revoked: "no",
+		Ranks:   map[string]int{"query-builder": 0, "query-builder-and-native": 1},
+		get:     func(p *client.GroupPermission) *client.PermissionValue { return p.CreateQueries },
+		set: func(value string) *client.GroupPermission {
+			return &client.GroupPermission{CreateQueries: client.PermissionLevel(value)}
+		},
+	}
+	downloadDimension = &permissionDimension{
+		Name:    "download",
+		Revoked: "none",
+		Ranks:   map[string]int{"limited": 0, "full": 1},
+		get: func(p *client.GroupPermission) *client.PermissionValue {
+			if p.Download == nil {
+				return nil
+			}
+			return p.Download.Schemas
+		},
+		set: func(value string) *client.GroupPermission {
+			return &client.GroupPermission{Download: &client.SchemasPermission{Schemas: client.PermissionLevel(value)}}
+		},
+	}
+	dataModelDimension = &permissionDimension{
+		Name:    "data-model",
+		Revoked: "none",
+		get: func(p *client.GroupPermission) *client.PermissionValue {
+			if p.DataModel == nil {
+				return nil
+			}
+			return p.DataModel.Schemas
+		},
+		set: func(value string) *client.GroupPermission {
+			return &client.GroupPermission{DataModel: &client.SchemasPermission{Schemas: client.PermissionLevel(value)}}
+		},
+	}
+	detailsDimension = &permissionDimension{
+		Name:         "details",
+		Revoked:      "no",
+		DatabaseOnly: true,
+		get: func(p *client.GroupPermission) *client.PermissionValue {
+			if p.Details == "" {
+				return nil
+			}
+			return client.PermissionLevel(p.Details)
+		},
+		set: func(value string) *client.GroupPermission { return &client.GroupPermission{Details: value} },
+	}
+)
+
+type databasePermission struct {
+	ID          string
+	DisplayName string
+	Dimension   *permissionDimension
+	Level       string
+	// PaidOnly permissions are only available on Metabase paid plans.
+	PaidOnly bool
+}
+
+// databasePermissions lists every level of the data permission graph that gives a group some access.
+// Levels that remove access (blocked, no, none) are not entitlements: a group without any grant of a
+// dimension has no access on it.
+var databasePermissions = []*databasePermission{
+	{ID: viewDataUnrestrictedPermission, DisplayName: "View Data (Can view)", Dimension: viewDataDimension, Level: "unrestricted"},
+	{ID: viewDataLegacyPermission, DisplayName: "View Data (No self-service, legacy)", Dimension: viewDataDimension, Level: "legacy-no-self-service"},
+	{ID: viewDataImpersonatedPermission, DisplayName: "View Data (Impersonated)", Dimension: viewDataDimension, Level: "impersonated", PaidOnly: true},
+	{ID: viewDataSandboxedPermission, DisplayName: "View Data (Sandboxed)", Dimension: viewDataDimension, Level: "sandboxed", PaidOnly: true},
+	{ID: queryBuilderPermission, DisplayName: "Query Builder", Dimension: createQueriesDimension, Level: "query-builder"},
+	{ID: queryBuilderAndNativePermission, DisplayName: "Query Builder and Native", Dimension: createQueriesDimension, Level: "query-builder-and-native"},
+	{ID: downloadFullPermission, DisplayName: "Download Results (Full)", Dimension: downloadDimension, Level: "full"},
+	{ID: downloadLimitedPermission, DisplayName: "Download Results (Limited)", Dimension: downloadDimension, Level: "limited", PaidOnly: true},
+	{ID: dataModelPermission, DisplayName: "Manage Table Metadata", Dimension: dataModelDimension, Level: "all", PaidOnly: true},
+	{ID: detailsPermission, DisplayName: "Manage Database", Dimension: detailsDimension, Level: "yes", PaidOnly: true},
+}
+
+// availablePermissions returns the data permissions available on the Metabase plan, restricted to the
+// dimensions that can be set per schema and table when granular is true.
+func availablePermissions(isPaidPlan bool, granular bool) []*databasePermission {
+	rv := make([]*databasePermission, 0, len(databasePermissions))
+	for _, permission := range databasePermissions {
+		if permission.PaidOnly && !isPaidPlan {
+			continue
+		}
+		if granular && permission.Dimension.DatabaseOnly {
+			continue
+		}
+		rv = append(rv, permission)
+	}
+	return rv
+}
+
+func findDatabasePermission(id string) (*databasePermission, error) {
+	for _, permission := range databasePermissions {
+		if permission.ID == id {
+			return permission, nil
+		}
+	}
+	return nil, fmt.Errorf("baton-metabase-v056: unknown database permission %s", id)
+}
+
+// permissionEntitlements builds one entitlement per permission, marked grantable to groups when grantable is true.
+// kind names the resource in the descriptions, e.g. "database" or "schema".
+func permissionEntitlements(resource *v2.Resource, permissions []*databasePermission, kind string, grantable bool) []*v2.Entitlement {
+	rv := make([]*v2.Entitlement, 0, len(permissions))
+	for _, permission := range permissions {
+		opts := []entitlement.EntitlementOption{
+			entitlement.WithDisplayName(fmt.Sprintf("%s %s", resource.DisplayName, permission.DisplayName)),
+			entitlement.WithDescription(fmt.Sprintf("Grants %s permission on the %s %s", permission.DisplayName, resource.DisplayName, kind)),
+		}
+		if grantable {
+			opts = append(opts, entitlement.WithGrantableTo(baseConnector.GroupResourceType))
+		}
+		rv = append(rv, entitlement.NewPermissionEntitlement(resource, permission.ID, opts...))
+	}
+	return rv
+}
+
+// newGroupGrant returns a grant of the entitlement to the group, expandable to the members of the group
+// and, on paid plans, to its managers.
+func newGroupGrant(resource *v2.Resource, entitlementName string, groupID string, isPaidPlan bool, opts ...grant.GrantOption) *v2.Grant {
+	groupResource := &v2.Resource{
+		Id: &v2.ResourceId{
+			ResourceType: baseConnector.GroupResourceType.Id,
+			Resource:     groupID,
+		},
+	}
+
+	entitlementIDs := []string{
+		fmt.Sprintf("%s:%s:%s", baseConnector.GroupResourceType.Id, groupID, baseConnector.MemberPermission),
+	}
+
+	if isPaidPlan {
+		entitlementIDs = append(entitlementIDs,
+			fmt.Sprintf("%s:%s:%s", baseConnector.GroupResourceType.Id, groupID, baseConnector.ManagerPermission),
+		)
+	}
+
+	opts = append(opts, grant.WithAnnotation(&v2.GrantExpandable{
+		EntitlementIds: entitlementIDs,
+	}))
+
+	return grant.NewGrant(resource, entitlementName, groupResource, opts...)
+}
+
+// permissionFromEntitlement returns the permission name, which is the last segment of the entitlement ID.
+func permissionFromEntitlement(ent *v2.Entitlement) string {
+	return ent.Id[strings.LastIndex(ent.Id, ":")+1:]
+}
+
+// granularPermissionGrants returns the grants of the permissions that a granular graph sets on the schema, or on
+// the table of the schema, at path. Permissions set on the whole database are synced on the database resource.
+func granularPermissionGrants(resource *v2.Resource, graph *client.DBPermissionGraph, dbID string, isPaidPlan bool, path ...string) []*v2.Grant {
+	var grants []*v2.Grant
+	for groupID, dbPermissions := range graph.Groups {
+		permissions, ok := dbPermissions[dbID]
+		if !ok || permissions == nil {
+			continue
+		}
+
+		for _, permission := range availablePermissions(isPaidPlan, true) {
+			value := permission.Dimension.get(permissions)
+			for _, key := range path {
+				value = value.Child(key)
+			}
+
+			if !permission.Dimension.includes(value.GetLevel(), permission.Level) {
+				continue
+			}
+
+			grants = append(grants, newGroupGrant(resource, permission.ID, groupID, isPaidPlan))
+		}
+	}
+	return grants
+}
+
+// updateGraphWithRetry runs update, which reads a permission graph and writes a change back, once more each time
+// Metabase reports that the graph revision changed in the meantime, up to maxGraphUpdateAttempts times.
+// It returns whether update changed the graph.
+func updateGraphWithRetry(ctx context.Context, update func() (bool, error)) (bool, error) {
+	l := ctxzap.Extract(ctx)
+
+	for attempt := 1; ; attempt++ {
+		changed, err := update()
+		if err == nil {
+			return changed, nil
+		}
+
+		if !errors.Is(err, client.ErrConflict) || attempt >= maxGraphUpdateAttempts {
+			return false, err
+		}
+
+		l.Debug("permission graph revision changed, retrying update", zap.Int("attempt", attempt), zap.Error(err))
+	}
+}
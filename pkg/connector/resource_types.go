@@ -9,4 +9,45 @@ var (
 		Id:          "database",
 		DisplayName: "Database",
 	}
+
+	schemaResourceType = &v2.ResourceType{
+		Id:          "schema",
+		DisplayName: "Schema",
+	}
+
+	tableResourceType = &v2.ResourceType{
+		Id:          "table",
+		DisplayName: "Table",
+	}
+
+	collectionResourceType = &v2.ResourceType{
+		Id:          "collection",
+		DisplayName: "Collection",
+	}
+
+	dashboardResourceType = &v2.ResourceType{
+		Id:          "dashboard",
+		DisplayName: "Dashboard",
+	}
+
+	cardResourceType = &v2.ResourceType{
+		Id:          "card",
+		DisplayName: "Card",
+	}
+
+	instanceResourceType = &v2.ResourceType{
+		Id:          "instance",
+		DisplayName: "Instance",
+	}
+
+	apiKeyResourceType = &v2.ResourceType{
+		Id:          "api_key",
+		DisplayName: "API Key",
+		Traits:      []v2.ResourceType_Trait{v2.ResourceType_TRAIT_USER},
+	}
+
+	applicationResourceType = &v2.ResourceType{
+		Id:          "application",
+		DisplayName: "Application Permissions",
+	}
 )
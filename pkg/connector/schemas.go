@@ -0,0 +1,108 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/conductorone/baton-metabase-v056/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+// noSchemaDisplayName is shown for the unnamed schema of databases that have no schemas, like MySQL.
+const noSchemaDisplayName = "(no schema)"
+
+type schemaBuilder struct {
+	client client.ClientService
+	cache  *syncCache
+}
+
+func (s *schemaBuilder) ResourceType(_ context.Context) *v2.ResourceType {
+	return schemaResourceType
+}
+
+func (s *schemaBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, _ resourceSdk.SyncOpAttrs) ([]*v2.Resource, *resourceSdk.SyncOpResults, error) {
+	if parentResourceID == nil {
+		return nil, nil, nil
+	}
+
+	ann := annotations.New()
+	dbID := parentResourceID.Resource
+
+	schemas, rateLimitDesc, err := s.client.ListSchemas(ctx, dbID)
+	if rateLimitDesc != nil {
+		ann.WithRateLimiting(rateLimitDesc)
+	}
+	if err != nil {
+		return nil, &resourceSdk.SyncOpResults{Annotations: ann}, err
+	}
+
+	outResources := make([]*v2.Resource, 0, len(schemas))
+	for _, schema := range schemas {
+		res, err := s.parseIntoSchemaResource(dbID, schema, parentResourceID)
+		if err != nil {
+			return nil, &resourceSdk.SyncOpResults{Annotations: ann}, err
+		}
+		outResources = append(outResources, res)
+	}
+
+	return outResources, &resourceSdk.SyncOpResults{Annotations: ann}, nil
+}
+
+func (s *schemaBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ resourceSdk.SyncOpAttrs) ([]*v2.Entitlement, *resourceSdk.SyncOpResults, error) {
+	// Schema permissions are synced but not provisioned: access is granted on the database.
+	return permissionEntitlements(resource, availablePermissions(s.client.IsPaidPlan(), true), "schema", false), nil, nil
+}
+
+func (s *schemaBuilder) Grants(ctx context.Context, resource *v2.Resource, attrs resourceSdk.SyncOpAttrs) ([]*v2.Grant, *resourceSdk.SyncOpResults, error) {
+	ann := annotations.New()
+
+	dbID, schema, err := parseSchemaResourceID(resource.Id.Resource)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	graph, err := fetchDatabaseGraph(ctx, s.cache, s.client, attrs.SyncID, dbID, &ann)
+	if err != nil {
+		return nil, &resourceSdk.SyncOpResults{Annotations: ann}, err
+	}
+
+	return granularPermissionGrants(resource, graph, dbID, s.client.IsPaidPlan(), schema), &resourceSdk.SyncOpResults{Annotations: ann}, nil
+}
+
+func (s *schemaBuilder) parseIntoSchemaResource(dbID string, schema string, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
+	displayName := schema
+	if displayName == "" {
+		displayName = noSchemaDisplayName
+	}
+
+	return resourceSdk.NewResource(
+		displayName,
+		schemaResourceType,
+		schemaResourceID(dbID, schema),
+		resourceSdk.WithParentResourceID(parentResourceID),
+		resourceSdk.WithAnnotation(&v2.ChildResourceType{ResourceTypeId: tableResourceType.Id}),
+	)
+}
+
+// schemaResourceID prefixes the schema name with its database ID, as schema names are only unique per database.
+func schemaResourceID(dbID string, schema string) string {
+	return fmt.Sprintf("%s:%s", dbID, schema)
+}
+
+func parseSchemaResourceID(id string) (string, string, error) {
+	dbID, schema, ok := strings.Cut(id, ":")
+	if !ok {
+		return "", "", fmt.Errorf("baton-metabase-v056: invalid schema resource id %s", id)
+	}
+	return dbID, schema, nil
+}
+
+func newSchemaBuilder(client client.ClientService, cache *syncCache) *schemaBuilder {
+	return &schemaBuilder{
+		client: client,
+		cache:  cache,
+	}
+}
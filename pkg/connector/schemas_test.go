@@ -0,0 +1,111 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/conductorone/baton-metabase-v056/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/stretchr/testify/require"
+)
+
+// granularGraphJSON is a v0.56 graph where group 3 has database-wide permissions and group 4 has
+// per-schema and per-table permissions.
+const granularGraphJSON = `{
+	"revision": 3,
+	"groups": {
+		"3": {"1": {"view-data": "unrestricted", "create-queries": "query-builder-and-native", "download": {"schemas": "full"}}},
+		"4": {"1": {
+			"view-data": {"PUBLIC": {"11": "unrestricted", "12": "blocked"}, "ANALYTICS": "unrestricted"},
+			"create-queries": {"PUBLIC": {"11": "query-builder"}, "ANALYTICS": "query-builder-and-native"},
+			"download": {"schemas": {"ANALYTICS": "full", "PUBLIC": {"11": "limited"}}}
+		}}
+	}
+}`
+
+func newGranularGraph(t *testing.T) *client.DBPermissionGraph {
+	var graph client.DBPermissionGraph
+	require.NoError(t, json.Unmarshal([]byte(granularGraphJSON), &graph))
+	return &graph
+}
+
+func grantedPermissions(grants []*v2.Grant) map[string][]string {
+	granted := map[string][]string{}
+	for _, g := range grants {
+		granted[g.Principal.Id.Resource] = append(granted[g.Principal.Id.Resource], permissionFromEntitlement(g.Entitlement))
+	}
+	return granted
+}
+
+func TestSchemasList(t *testing.T) {
+	ctx := context.Background()
+	dbID := &v2.ResourceId{ResourceType: databaseResourceType.Id, Resource: "1"}
+
+	t.Run("should list schemas as children of the database", func(t *testing.T) {
+		mockClient := &client.MockService{}
+		builder := newSchemaBuilder(mockClient, newSyncCache())
+		mockClient.ListSchemasFunc = func(ctx context.Context, dbID string) ([]string, *v2.RateLimitDescription, error) {
+			return []string{"PUBLIC", ""}, nil, nil
+		}
+
+		resources, _, err := builder.List(ctx, dbID, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.NoError(t, err)
+		require.Len(t, resources, 2)
+		require.Equal(t, "1:PUBLIC", resources[0].Id.Resource)
+		require.Equal(t, dbID, resources[0].ParentResourceId)
+		require.Equal(t, noSchemaDisplayName, resources[1].DisplayName)
+	})
+
+	t.Run("should return nothing without a parent database", func(t *testing.T) {
+		builder := newSchemaBuilder(&client.MockService{}, newSyncCache())
+
+		resources, _, err := builder.List(ctx, nil, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.NoError(t, err)
+		require.Empty(t, resources)
+	})
+
+	t.Run("should return error if ListSchemas fails", func(t *testing.T) {
+		mockClient := &client.MockService{}
+		builder := newSchemaBuilder(mockClient, newSyncCache())
+		mockClient.ListSchemasFunc = func(ctx context.Context, dbID string) ([]string, *v2.RateLimitDescription, error) {
+			return nil, nil, fmt.Errorf("API error")
+		}
+
+		_, _, err := builder.List(ctx, dbID, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.Error(t, err)
+	})
+}
+
+func TestSchemasGrants(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should return only permissions set on the schema", func(t *testing.T) {
+		mockClient := &client.MockService{IsPaidPlanFunc: func() bool { return true }}
+		builder := newSchemaBuilder(mockClient, newSyncCache())
+		mockClient.GetDBPermissionsFunc = func(ctx context.Context, dbID string) (*client.DBPermissionGraph, *v2.RateLimitDescription, error) {
+			return newGranularGraph(t), nil, nil
+		}
+
+		analytics := &v2.Resource{Id: &v2.ResourceId{ResourceType: schemaResourceType.Id, Resource: "1:ANALYTICS"}}
+		grants, _, err := builder.Grants(ctx, analytics, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.NoError(t, err)
+
+		granted := grantedPermissions(grants)
+		require.Empty(t, granted["3"])
+		require.ElementsMatch(t, []string{
+			viewDataUnrestrictedPermission,
+			queryBuilderPermission,
+			queryBuilderAndNativePermission,
+			downloadFullPermission,
+			downloadLimitedPermission,
+		}, granted["4"])
+
+		public := &v2.Resource{Id: &v2.ResourceId{ResourceType: schemaResourceType.Id, Resource: "1:PUBLIC"}}
+		grants, _, err = builder.Grants(ctx, public, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.NoError(t, err)
+		require.Empty(t, grants)
+	})
+}
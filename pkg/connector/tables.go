@@ -0,0 +1,255 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/conductorone/baton-metabase-v056/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// sandboxedLevel is the view-data level of a table that is sandboxed for a group.
+const sandboxedLevel = "sandboxed"
+
+type tableBuilder struct {
+	client client.ClientService
+	cache  *syncCache
+}
+
+func (t *tableBuilder) ResourceType(_ context.Context) *v2.ResourceType {
+	return tableResourceType
+}
+
+func (t *tableBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, _ resourceSdk.SyncOpAttrs) ([]*v2.Resource, *resourceSdk.SyncOpResults, error) {
+	if parentResourceID == nil {
+		return nil, nil, nil
+	}
+
+	ann := annotations.New()
+
+	dbID, schema, err := parseSchemaResourceID(parentResourceID.Resource)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tables, rateLimitDesc, err := t.client.ListTables(ctx, dbID, schema)
+	if rateLimitDesc != nil {
+		ann.WithRateLimiting(rateLimitDesc)
+	}
+	if err != nil {
+		return nil, &resourceSdk.SyncOpResults{Annotations: ann}, err
+	}
+
+	outResources := make([]*v2.Resource, 0, len(tables))
+	for _, table := range tables {
+		res, err := t.parseIntoTableResource(dbID, table, parentResourceID)
+		if err != nil {
+			return nil, &resourceSdk.SyncOpResults{Annotations: ann}, err
+		}
+		outResources = append(outResources, res)
+	}
+
+	return outResources, &resourceSdk.SyncOpResults{Annotations: ann}, nil
+}
+
+func (t *tableBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ resourceSdk.SyncOpAttrs) ([]*v2.Entitlement, *resourceSdk.SyncOpResults, error) {
+	// Table permissions are synced but not provisioned: access is granted on the database, and a sandbox needs a
+	// filter that a grant cannot carry, so it is created and deleted with the sandbox_table and unsandbox_table actions.
+	return permissionEntitlements(resource, availablePermissions(t.client.IsPaidPlan(), true), "table", false), nil, nil
+}
+
+func (t *tableBuilder) Grants(ctx context.Context, resource *v2.Resource, attrs resourceSdk.SyncOpAttrs) ([]*v2.Grant, *resourceSdk.SyncOpResults, error) {
+	ann := annotations.New()
+
+	dbID, schema, tableID, err := parseTableResourceID(resource.Id.Resource)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	graph, err := fetchDatabaseGraph(ctx, t.cache, t.client, attrs.SyncID, dbID, &ann)
+	if err != nil {
+		return nil, &resourceSdk.SyncOpResults{Annotations: ann}, err
+	}
+
+	grants := granularPermissionGrants(resource, graph, dbID, t.client.IsPaidPlan(), schema, tableID)
+	if !t.client.IsPaidPlan() {
+		return grants, &resourceSdk.SyncOpResults{Annotations: ann}, nil
+	}
+
+	// Sandboxed access is synced from the sandboxes themselves, which tell how the rows are filtered.
+	rv := make([]*v2.Grant, 0, len(grants))
+	for _, g := range grants {
+		if permissionFromEntitlement(g.Entitlement) != viewDataSandboxedPermission {
+			rv = append(rv, g)
+		}
+	}
+
+	sandboxes, err := fetchSandboxes(ctx, t.cache, t.client, attrs.SyncID, tableID, &ann)
+	if err != nil {
+		return nil, &resourceSdk.SyncOpResults{Annotations: ann}, err
+	}
+
+	for _, sandbox := range sandboxes {
+		g, err := newSandboxGrant(resource, sandbox)
+		if err != nil {
+			return nil, &resourceSdk.SyncOpResults{Annotations: ann}, err
+		}
+		rv = append(rv, g)
+	}
+
+	return rv, &resourceSdk.SyncOpResults{Annotations: ann}, nil
+}
+
+// updateTableViewData reads the permission graph of the database and writes back the view-data level returned by
+// mutate for the group on the table. mutate gets the level that applies to the table, whether it is set on the
+// database, the schema or the table, and returns an empty level when nothing is to be written. It returns whether
+// the graph changed.
+func updateTableViewData(
+	ctx context.Context,
+	cl client.ClientService,
+	dbID string,
+	schema string,
+	tableID int,
+	groupID int,
+	mutate func(current string) string,
+	ann *annotations.Annotations,
+) (bool, error) {
+	groupKey := strconv.Itoa(groupID)
+	tableKey := strconv.Itoa(tableID)
+
+	return updateGraphWithRetry(ctx, func() (bool, error) {
+		graph, rateLimitDesc, err := cl.GetDBPermissions(ctx, dbID)
+		if rateLimitDesc != nil {
+			ann.WithRateLimiting(rateLimitDesc)
+		}
+		if err != nil {
+			return false, err
+		}
+
+		var current string
+		if permissions := graph.Groups[groupKey][dbID]; permissions != nil {
+			current = effectiveLevel(viewDataDimension.get(permissions), schema, tableKey)
+		}
+
+		level := mutate(current)
+		if level == "" {
+			return false, nil
+		}
+
+		rateLimitDesc, err = cl.UpdatePermissionGraph(ctx, &client.DBPermissionGraph{
+			Revision: graph.Revision,
+			Groups: map[string]map[string]*client.GroupPermission{
+				groupKey: {dbID: {ViewData: &client.PermissionValue{Children: map[string]*client.PermissionValue{
+					schema: {Children: map[string]*client.PermissionValue{tableKey: client.PermissionLevel(level)}},
+				}}}},
+			},
+		})
+		if rateLimitDesc != nil {
+			ann.WithRateLimiting(rateLimitDesc)
+		}
+		if err != nil {
+			return false, err
+		}
+		return true, nil
+	})
+}
+
+// effectiveLevel returns the level that applies at path, set either there or on one of its ancestors.
+func effectiveLevel(value *client.PermissionValue, path ...string) string {
+	for _, key := range path {
+		if level := value.GetLevel(); level != "" {
+			return level
+		}
+		value = value.Child(key)
+	}
+	return value.GetLevel()
+}
+
+// findSandbox returns the sandbox of the table for the group, or nil when the group has none.
+func findSandbox(ctx context.Context, cl client.ClientService, tableID int, groupID int) (*client.Sandbox, *v2.RateLimitDescription, error) {
+	sandboxes, rateLimitDesc, err := cl.ListSandboxes(ctx, strconv.Itoa(tableID))
+	if err != nil {
+		return nil, rateLimitDesc, err
+	}
+
+	for _, sandbox := range sandboxes {
+		if sandbox.GroupID == groupID {
+			return sandbox, rateLimitDesc, nil
+		}
+	}
+
+	return nil, rateLimitDesc, nil
+}
+
+// newSandboxGrant returns the grant of sandboxed access to the group of the sandbox, with the card and attribute
+// remappings that filter the rows in its metadata.
+func newSandboxGrant(resource *v2.Resource, sandbox *client.Sandbox) (*v2.Grant, error) {
+	metadata := map[string]interface{}{
+		"sandbox_id": sandbox.ID,
+	}
+
+	if sandbox.CardID != nil {
+		metadata["card_id"] = *sandbox.CardID
+	}
+
+	if len(sandbox.AttributeRemappings) > 0 {
+		metadata["attribute_remappings"] = sandbox.AttributeRemappings
+	}
+
+	metadataStruct, err := structpb.NewStruct(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert sandbox %d: %w", sandbox.ID, err)
+	}
+
+	return newGroupGrant(resource, viewDataSandboxedPermission, strconv.Itoa(sandbox.GroupID), true,
+		grant.WithAnnotation(&v2.GrantMetadata{Metadata: metadataStruct}),
+	), nil
+}
+
+func (t *tableBuilder) parseIntoTableResource(dbID string, table *client.Table, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
+	displayName := table.DisplayName
+	if displayName == "" {
+		displayName = table.Name
+	}
+
+	return resourceSdk.NewResource(
+		displayName,
+		tableResourceType,
+		tableResourceID(dbID, table.Schema, table.ID),
+		resourceSdk.WithParentResourceID(parentResourceID),
+		resourceSdk.WithDescription(table.Description),
+	)
+}
+
+// tableResourceID keeps the database and schema of the table in its ID, as the permission graph of a table
+// is nested under both.
+func tableResourceID(dbID string, schema string, tableID int) string {
+	return fmt.Sprintf("%s:%d", schemaResourceID(dbID, schema), tableID)
+}
+
+func parseTableResourceID(id string) (string, string, string, error) {
+	idx := strings.LastIndex(id, ":")
+	if idx < 0 {
+		return "", "", "", fmt.Errorf("baton-metabase-v056: invalid table resource id %s", id)
+	}
+
+	dbID, schema, err := parseSchemaResourceID(id[:idx])
+	if err != nil {
+		return "", "", "", fmt.Errorf("baton-metabase-v056: invalid table resource id %s", id)
+	}
+
+	return dbID, schema, id[idx+1:], nil
+}
+
+func newTableBuilder(client client.ClientService, cache *syncCache) *tableBuilder {
+	return &tableBuilder{
+		client: client,
+		cache:  cache,
+	}
+}
@@ -0,0 +1,333 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/conductorone/baton-metabase-v056/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestTablesList(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should list tables as children of the schema", func(t *testing.T) {
+		mockClient := &client.MockService{}
+		builder := newTableBuilder(mockClient, newSyncCache())
+		mockClient.ListTablesFunc = func(ctx context.Context, dbID string, schema string) ([]*client.Table, *v2.RateLimitDescription, error) {
+			require.Equal(t, "1", dbID)
+			require.Equal(t, "PUBLIC", schema)
+			return []*client.Table{{ID: 11, Schema: "PUBLIC", Name: "ORDERS", DisplayName: "Orders"}}, nil, nil
+		}
+
+		schemaID := &v2.ResourceId{ResourceType: schemaResourceType.Id, Resource: "1:PUBLIC"}
+		resources, _, err := builder.List(ctx, schemaID, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.NoError(t, err)
+		require.Len(t, resources, 1)
+		require.Equal(t, "Orders", resources[0].DisplayName)
+		require.Equal(t, "1:PUBLIC:11", resources[0].Id.Resource)
+		require.Equal(t, schemaID, resources[0].ParentResourceId)
+	})
+}
+
+func TestTablesEntitlements(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should not offer table permissions as grantable", func(t *testing.T) {
+		builder := newTableBuilder(&client.MockService{IsPaidPlanFunc: func() bool { return true }}, newSyncCache())
+		table := &v2.Resource{Id: &v2.ResourceId{ResourceType: tableResourceType.Id, Resource: "1:PUBLIC:12"}, DisplayName: "Customers"}
+
+		entitlements, _, err := builder.Entitlements(ctx, table, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.NoError(t, err)
+		require.NotEmpty(t, entitlements)
+		for _, ent := range entitlements {
+			require.Empty(t, ent.GrantableTo, ent.Slug)
+		}
+	})
+}
+
+func TestTablesGrants(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should return only permissions set on the table", func(t *testing.T) {
+		mockClient := &client.MockService{IsPaidPlanFunc: func() bool { return true }}
+		builder := newTableBuilder(mockClient, newSyncCache())
+		mockClient.GetDBPermissionsFunc = func(ctx context.Context, dbID string) (*client.DBPermissionGraph, *v2.RateLimitDescription, error) {
+			return newGranularGraph(t), nil, nil
+		}
+		mockClient.ListSandboxesFunc = func(ctx context.Context, tableID string) ([]*client.Sandbox, *v2.RateLimitDescription, error) {
+			return nil, nil, nil
+		}
+
+		orders := &v2.Resource{Id: &v2.ResourceId{ResourceType: tableResourceType.Id, Resource: "1:PUBLIC:11"}}
+		grants, _, err := builder.Grants(ctx, orders, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{viewDataUnrestrictedPermission, queryBuilderPermission, downloadLimitedPermission}, grantedPermissions(grants)["4"])
+
+		customers := &v2.Resource{Id: &v2.ResourceId{ResourceType: tableResourceType.Id, Resource: "1:PUBLIC:12"}}
+		grants, _, err = builder.Grants(ctx, customers, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.NoError(t, err)
+		require.Empty(t, grants)
+	})
+
+	t.Run("should return sandboxes with their filter from a single listing per sync", func(t *testing.T) {
+		mockClient := &client.MockService{IsPaidPlanFunc: func() bool { return true }}
+		builder := newTableBuilder(mockClient, newSyncCache())
+		mockClient.GetDBPermissionsFunc = func(ctx context.Context, dbID string) (*client.DBPermissionGraph, *v2.RateLimitDescription, error) {
+			return &client.DBPermissionGraph{Groups: map[string]map[string]*client.GroupPermission{
+				"3": {"1": {ViewData: client.PermissionLevel("sandboxed")}},
+			}}, nil, nil
+		}
+		cardID := 21
+		listings := 0
+		mockClient.ListSandboxesFunc = func(ctx context.Context, tableID string) ([]*client.Sandbox, *v2.RateLimitDescription, error) {
+			require.Empty(t, tableID)
+			listings++
+			return []*client.Sandbox{
+				{
+					ID:                  5,
+					GroupID:             3,
+					TableID:             12,
+					CardID:              &cardID,
+					AttributeRemappings: map[string]interface{}{"tenant_id": []interface{}{"dimension", []interface{}{"field", 45, nil}}},
+				},
+				{ID: 6, GroupID: 4, TableID: 13, CardID: &cardID},
+			}, nil, nil
+		}
+
+		customers := &v2.Resource{Id: &v2.ResourceId{ResourceType: tableResourceType.Id, Resource: "1:PUBLIC:12"}}
+		grants, _, err := builder.Grants(ctx, customers, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.NoError(t, err)
+		require.Len(t, grants, 1)
+		require.Equal(t, map[string][]string{"3": {viewDataSandboxedPermission}}, grantedPermissions(grants))
+
+		metadata := &v2.GrantMetadata{}
+		grantAnnotations := annotations.Annotations(grants[0].Annotations)
+		ok, err := grantAnnotations.Pick(metadata)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, float64(21), metadata.Metadata.Fields["card_id"].GetNumberValue())
+		require.Contains(t, metadata.Metadata.Fields["attribute_remappings"].GetStructValue().Fields, "tenant_id")
+
+		orders := &v2.Resource{Id: &v2.ResourceId{ResourceType: tableResourceType.Id, Resource: "1:PUBLIC:11"}}
+		grants, _, err = builder.Grants(ctx, orders, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.NoError(t, err)
+		require.Empty(t, grants)
+		require.Equal(t, 1, listings)
+	})
+
+	t.Run("should return error for an invalid table id", func(t *testing.T) {
+		builder := newTableBuilder(&client.MockService{}, newSyncCache())
+
+		invalid := &v2.Resource{Id: &v2.ResourceId{ResourceType: tableResourceType.Id, Resource: "11"}}
+		_, _, err := builder.Grants(ctx, invalid, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.Error(t, err)
+	})
+}
+
+func TestSandboxTable(t *testing.T) {
+	ctx := context.Background()
+
+	newArgs := func(t *testing.T, cardID string, remappings map[string]interface{}) *structpb.Struct {
+		args := &structpb.Struct{Fields: map[string]*structpb.Value{
+			"groupId": structpb.NewStringValue("3"),
+			"tableId": structpb.NewStringValue("12"),
+		}}
+		if cardID != "" {
+			args.Fields["cardId"] = structpb.NewStringValue(cardID)
+		}
+		if remappings != nil {
+			remappingsStruct, err := structpb.NewStruct(remappings)
+			require.NoError(t, err)
+			args.Fields["attributeRemappings"] = structpb.NewStructValue(remappingsStruct)
+		}
+		return args
+	}
+
+	newSandboxTestClient := func(created **client.Sandbox, sandboxes ...*client.Sandbox) *client.MockService {
+		return &client.MockService{
+			IsPaidPlanFunc: func() bool { return true },
+			ListSandboxesFunc: func(ctx context.Context, tableID string) ([]*client.Sandbox, *v2.RateLimitDescription, error) {
+				require.Equal(t, "12", tableID)
+				return sandboxes, nil, nil
+			},
+			GetTableFunc: func(ctx context.Context, tableID string) (*client.Table, *v2.RateLimitDescription, error) {
+				require.Equal(t, "12", tableID)
+				return &client.Table{ID: 12, DBID: 1, Schema: "PUBLIC", Name: "CUSTOMERS"}, nil, nil
+			},
+			CreateSandboxFunc: func(ctx context.Context, sandbox *client.Sandbox) (*client.Sandbox, *v2.RateLimitDescription, error) {
+				*created = sandbox
+				return &client.Sandbox{ID: 9, GroupID: sandbox.GroupID, TableID: sandbox.TableID}, nil, nil
+			},
+			GetDBPermissionsFunc: func(ctx context.Context, dbID string) (*client.DBPermissionGraph, *v2.RateLimitDescription, error) {
+				require.Equal(t, "1", dbID)
+				return &client.DBPermissionGraph{Revision: 7, Groups: map[string]map[string]*client.GroupPermission{
+					"3": {"1": {ViewData: client.PermissionLevel("unrestricted")}},
+				}}, nil, nil
+			},
+			UpdatePermissionGraphFunc: func(ctx context.Context, graph *client.DBPermissionGraph) (*v2.RateLimitDescription, error) {
+				return nil, nil
+			},
+		}
+	}
+
+	t.Run("should create the sandbox with its filter", func(t *testing.T) {
+		var created *client.Sandbox
+		c := &Connector{v056Client: newSandboxTestClient(&created)}
+
+		remappings := map[string]interface{}{"tenant_id": []interface{}{"dimension", []interface{}{"field", 40.0, nil}}}
+		resp, _, err := c.SandboxTable(ctx, newArgs(t, "", remappings))
+		require.NoError(t, err)
+		require.Equal(t, "9", resp.Fields["sandboxId"].GetStringValue())
+		require.Equal(t, 3, created.GroupID)
+		require.Equal(t, 12, created.TableID)
+		require.Nil(t, created.CardID)
+		require.Equal(t, remappings, created.AttributeRemappings)
+	})
+
+	t.Run("should sandbox the table in the permission graph", func(t *testing.T) {
+		var created *client.Sandbox
+		mockClient := newSandboxTestClient(&created)
+		var written *client.DBPermissionGraph
+		mockClient.UpdatePermissionGraphFunc = func(ctx context.Context, graph *client.DBPermissionGraph) (*v2.RateLimitDescription, error) {
+			require.NotNil(t, created, "the sandbox must exist before the table is sandboxed")
+			written = graph
+			return nil, nil
+		}
+		c := &Connector{v056Client: mockClient}
+
+		_, _, err := c.SandboxTable(ctx, newArgs(t, "21", nil))
+		require.NoError(t, err)
+		require.Equal(t, 7, written.Revision)
+		require.Equal(t, "sandboxed", written.Groups["3"]["1"].ViewData.Child("PUBLIC").Child("12").GetLevel())
+	})
+
+	t.Run("should delete the sandbox when the table cannot be sandboxed", func(t *testing.T) {
+		var created *client.Sandbox
+		mockClient := newSandboxTestClient(&created)
+		mockClient.UpdatePermissionGraphFunc = func(ctx context.Context, graph *client.DBPermissionGraph) (*v2.RateLimitDescription, error) {
+			return nil, fmt.Errorf("API error")
+		}
+		var deleted string
+		mockClient.DeleteSandboxFunc = func(ctx context.Context, sandboxID string) (*v2.RateLimitDescription, error) {
+			deleted = sandboxID
+			return nil, nil
+		}
+		c := &Connector{v056Client: mockClient}
+
+		_, _, err := c.SandboxTable(ctx, newArgs(t, "21", nil))
+		require.Error(t, err)
+		require.Equal(t, "9", deleted)
+	})
+
+	t.Run("should filter with a card", func(t *testing.T) {
+		var created *client.Sandbox
+		c := &Connector{v056Client: newSandboxTestClient(&created)}
+
+		_, _, err := c.SandboxTable(ctx, newArgs(t, "21", nil))
+		require.NoError(t, err)
+		require.NotNil(t, created.CardID)
+		require.Equal(t, 21, *created.CardID)
+	})
+
+	t.Run("should require a filter", func(t *testing.T) {
+		var created *client.Sandbox
+		c := &Connector{v056Client: newSandboxTestClient(&created)}
+
+		_, _, err := c.SandboxTable(ctx, newArgs(t, "", nil))
+		require.Error(t, err)
+		require.Nil(t, created)
+	})
+
+	t.Run("should not replace the sandbox of the group", func(t *testing.T) {
+		var created *client.Sandbox
+		c := &Connector{v056Client: newSandboxTestClient(&created, &client.Sandbox{ID: 5, GroupID: 3, TableID: 12})}
+
+		_, _, err := c.SandboxTable(ctx, newArgs(t, "21", nil))
+		require.Error(t, err)
+		require.Nil(t, created)
+	})
+}
+
+func TestUnsandboxTable(t *testing.T) {
+	ctx := context.Background()
+	args := &structpb.Struct{Fields: map[string]*structpb.Value{
+		"groupId": structpb.NewStringValue("3"),
+		"tableId": structpb.NewStringValue("12"),
+	}}
+
+	// newUnsandboxTestClient makes the graph of database 1 give group 3 the view-data value, and records the graph
+	// written.
+	newUnsandboxTestClient := func(viewData *client.PermissionValue, written **client.DBPermissionGraph, sandboxes ...*client.Sandbox) *client.MockService {
+		return &client.MockService{
+			IsPaidPlanFunc: func() bool { return true },
+			ListSandboxesFunc: func(ctx context.Context, tableID string) ([]*client.Sandbox, *v2.RateLimitDescription, error) {
+				require.Equal(t, "12", tableID)
+				return sandboxes, nil, nil
+			},
+			GetTableFunc: func(ctx context.Context, tableID string) (*client.Table, *v2.RateLimitDescription, error) {
+				require.Equal(t, "12", tableID)
+				return &client.Table{ID: 12, DBID: 1, Schema: "PUBLIC", Name: "CUSTOMERS"}, nil, nil
+			},
+			GetDBPermissionsFunc: func(ctx context.Context, dbID string) (*client.DBPermissionGraph, *v2.RateLimitDescription, error) {
+				require.Equal(t, "1", dbID)
+				return &client.DBPermissionGraph{Revision: 7, Groups: map[string]map[string]*client.GroupPermission{
+					"3": {"1": {ViewData: viewData}},
+				}}, nil, nil
+			},
+			UpdatePermissionGraphFunc: func(ctx context.Context, graph *client.DBPermissionGraph) (*v2.RateLimitDescription, error) {
+				*written = graph
+				return nil, nil
+			},
+		}
+	}
+
+	sandboxedTable := &client.PermissionValue{Children: map[string]*client.PermissionValue{
+		"PUBLIC": {Children: map[string]*client.PermissionValue{"11": client.PermissionLevel("unrestricted"), "12": client.PermissionLevel("sandboxed")}},
+	}}
+
+	t.Run("should block the table and delete the sandbox of the group", func(t *testing.T) {
+		var written *client.DBPermissionGraph
+		mockClient := newUnsandboxTestClient(sandboxedTable, &written, &client.Sandbox{ID: 4, GroupID: 2, TableID: 12}, &client.Sandbox{ID: 5, GroupID: 3, TableID: 12})
+		var deleted string
+		mockClient.DeleteSandboxFunc = func(ctx context.Context, sandboxID string) (*v2.RateLimitDescription, error) {
+			require.NotNil(t, written, "the table must be blocked before its sandbox is deleted")
+			deleted = sandboxID
+			return nil, nil
+		}
+		c := &Connector{v056Client: mockClient}
+
+		resp, _, err := c.UnsandboxTable(ctx, args)
+		require.NoError(t, err)
+		require.True(t, resp.Fields["changed"].GetBoolValue())
+		require.Equal(t, "5", deleted)
+		require.Equal(t, 7, written.Revision)
+		require.Equal(t, "blocked", written.Groups["3"]["1"].ViewData.Child("PUBLIC").Child("12").GetLevel())
+		require.Len(t, written.Groups["3"]["1"].ViewData.Child("PUBLIC").Children, 1)
+	})
+
+	t.Run("should block a table left sandboxed without a sandbox", func(t *testing.T) {
+		var written *client.DBPermissionGraph
+		c := &Connector{v056Client: newUnsandboxTestClient(sandboxedTable, &written)}
+
+		resp, _, err := c.UnsandboxTable(ctx, args)
+		require.NoError(t, err)
+		require.True(t, resp.Fields["changed"].GetBoolValue())
+		require.Equal(t, "blocked", written.Groups["3"]["1"].ViewData.Child("PUBLIC").Child("12").GetLevel())
+	})
+
+	t.Run("should report a table that is not sandboxed", func(t *testing.T) {
+		var written *client.DBPermissionGraph
+		c := &Connector{v056Client: newUnsandboxTestClient(client.PermissionLevel("unrestricted"), &written)}
+
+		resp, _, err := c.UnsandboxTable(ctx, args)
+		require.NoError(t, err)
+		require.False(t, resp.Fields["changed"].GetBoolValue())
+		require.Nil(t, written)
+	})
+}
@@ -0,0 +1,276 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/conductorone/baton-metabase-v056/pkg/client"
+	baseConnector "github.com/conductorone/baton-metabase/pkg/connector"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/conductorone/baton-sdk/pkg/crypto"
+	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+)
+
+// userBuilder syncs users with the resource type and IDs of the base connector, so that syncs stay comparable
+// whichever way the connector authenticates.
+type userBuilder struct {
+	client      client.ClientService
+	deprovision deprovisionOptions
+}
+
+// deprovisionOptions select what is done when a user is deleted, before the user is deactivated.
+type deprovisionOptions struct {
+	removeMemberships         bool
+	archivePersonalCollection bool
+	// transferCollectionID is the collection that receives the content of the personal collection, if not empty.
+	transferCollectionID client.CollectionID
+}
+
+func (u *userBuilder) ResourceType(_ context.Context) *v2.ResourceType {
+	return baseConnector.UserResourceType
+}
+
+func (u *userBuilder) List(ctx context.Context, _ *v2.ResourceId, attrs resourceSdk.SyncOpAttrs) ([]*v2.Resource, *resourceSdk.SyncOpResults, error) {
+	opts, err := getPageOptions(&attrs.PageToken, resourcePageSize)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ann := annotations.New()
+
+	users, total, rateLimitDesc, err := u.client.ListUsers(ctx, opts)
+	if rateLimitDesc != nil {
+		ann.WithRateLimiting(rateLimitDesc)
+	}
+	if err != nil {
+		return nil, &resourceSdk.SyncOpResults{Annotations: ann}, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	outResources := make([]*v2.Resource, 0, len(users))
+	for _, user := range users {
+		res, err := parseIntoUserResource(user)
+		if err != nil {
+			return nil, &resourceSdk.SyncOpResults{Annotations: ann}, err
+		}
+		outResources = append(outResources, res)
+	}
+
+	return outResources, &resourceSdk.SyncOpResults{NextPageToken: getNextPageToken(opts.Offset, opts.Limit, total), Annotations: ann}, nil
+}
+
+func (u *userBuilder) Entitlements(_ context.Context, _ *v2.Resource, _ resourceSdk.SyncOpAttrs) ([]*v2.Entitlement, *resourceSdk.SyncOpResults, error) {
+	return nil, nil, nil
+}
+
+// Grants returns no grants: group memberships are synced on groups.
+func (u *userBuilder) Grants(_ context.Context, _ *v2.Resource, _ resourceSdk.SyncOpAttrs) ([]*v2.Grant, *resourceSdk.SyncOpResults, error) {
+	return nil, nil, nil
+}
+
+func (u *userBuilder) CreateAccountCapabilityDetails(_ context.Context) (*v2.CredentialDetailsAccountProvisioning, annotations.Annotations, error) {
+	return &v2.CredentialDetailsAccountProvisioning{
+		SupportedCredentialOptions: []v2.CapabilityDetailCredentialOption{
+			v2.CapabilityDetailCredentialOption_CAPABILITY_DETAIL_CREDENTIAL_OPTION_RANDOM_PASSWORD,
+		},
+		PreferredCredentialOption: v2.CapabilityDetailCredentialOption_CAPABILITY_DETAIL_CREDENTIAL_OPTION_RANDOM_PASSWORD,
+	}, nil, nil
+}
+
+// CreateAccount creates the user with a random password, returned so that it can be handed to the user.
+func (u *userBuilder) CreateAccount(
+	ctx context.Context,
+	accountInfo *v2.AccountInfo,
+	_ *v2.LocalCredentialOptions,
+) (connectorbuilder.CreateAccountResponse, []*v2.PlaintextData, annotations.Annotations, error) {
+	profile := accountInfo.GetProfile().AsMap()
+
+	request := &client.CreateUserRequest{}
+	for name, value := range map[string]*string{
+		"email":      &request.Email,
+		"first_name": &request.FirstName,
+		"last_name":  &request.LastName,
+	} {
+		v, ok := profile[name].(string)
+		if !ok || v == "" {
+			return nil, nil, nil, fmt.Errorf("baton-metabase-v056: missing required field: %s", name)
+		}
+		*value = v
+	}
+
+	password, err := crypto.GenerateRandomPassword(&v2.LocalCredentialOptions_RandomPassword{
+		Length: 12,
+	})
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to generate password: %w", err)
+	}
+	request.Password = password
+
+	ann := annotations.New()
+
+	user, rateLimitDesc, err := u.client.CreateUser(ctx, request)
+	if rateLimitDesc != nil {
+		ann.WithRateLimiting(rateLimitDesc)
+	}
+	if err != nil {
+		return nil, nil, ann, err
+	}
+
+	userResource, err := parseIntoUserResource(user)
+	if err != nil {
+		return nil, nil, ann, err
+	}
+
+	resp := &v2.CreateAccountResponse_SuccessResult{
+		Resource:              userResource,
+		IsCreateAccountResult: true,
+	}
+
+	plaintexts := []*v2.PlaintextData{
+		{
+			Name:  "password",
+			Bytes: []byte(password),
+		},
+	}
+
+	return resp, plaintexts, ann, nil
+}
+
+// Delete deactivates the user, since Metabase cannot delete users. Depending on the deprovisioning options, the
+// group memberships of the user are removed and the content of the personal collection is archived or transferred
+// first, so that a failed deletion can be retried.
+func (u *userBuilder) Delete(ctx context.Context, resourceId *v2.ResourceId, _ *v2.ResourceId) (annotations.Annotations, error) {
+	if resourceId.ResourceType != baseConnector.UserResourceType.Id {
+		return nil, fmt.Errorf("baton-metabase-v056: only users can be deleted, got %s", resourceId.ResourceType)
+	}
+
+	l := ctxzap.Extract(ctx)
+	ann := annotations.New()
+	userID := resourceId.Resource
+
+	user, rateLimitDesc, err := u.client.GetUser(ctx, userID)
+	if rateLimitDesc != nil {
+		ann.WithRateLimiting(rateLimitDesc)
+	}
+	if err != nil {
+		return ann, err
+	}
+
+	if u.deprovision.removeMemberships {
+		if err := u.removeMemberships(ctx, userID, &ann); err != nil {
+			return ann, err
+		}
+	}
+
+	if update := u.personalCollectionUpdate(); update != nil && user.PersonalCollectionID != nil {
+		counts, err := updateCollectionItems(ctx, u.client, *user.PersonalCollectionID, update, &ann)
+		if err != nil {
+			return ann, fmt.Errorf("failed to clear personal collection of user %s: %w", userID, err)
+		}
+		l.Debug("cleared personal collection of deleted user",
+			zap.String("user_id", userID),
+			zap.Int("collection_id", *user.PersonalCollectionID),
+			zap.Any("items", counts),
+		)
+	}
+
+	if !user.IsActive {
+		l.Debug("user already inactive, skipping deactivation", zap.String("user_id", userID))
+		return ann, nil
+	}
+
+	_, rateLimitDesc, err = u.client.UpdateUserActiveStatus(ctx, userID, false)
+	if rateLimitDesc != nil {
+		ann.WithRateLimiting(rateLimitDesc)
+	}
+	if err != nil {
+		return ann, err
+	}
+
+	return ann, nil
+}
+
+// removeMemberships removes the user from every group but All Users, which every user belongs to.
+func (u *userBuilder) removeMemberships(ctx context.Context, userID string, ann *annotations.Annotations) error {
+	memberships, rateLimitDesc, err := u.client.ListMemberships(ctx)
+	if rateLimitDesc != nil {
+		ann.WithRateLimiting(rateLimitDesc)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list memberships: %w", err)
+	}
+
+	for _, membership := range memberships[userID] {
+		if membership.GroupID == client.AllUsersGroupID {
+			continue
+		}
+
+		rateLimitDesc, err := u.client.RemoveUserFromGroup(ctx, strconv.Itoa(membership.MembershipID))
+		if rateLimitDesc != nil {
+			ann.WithRateLimiting(rateLimitDesc)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to remove user %s from group %d: %w", userID, membership.GroupID, err)
+		}
+	}
+
+	return nil
+}
+
+// personalCollectionUpdate returns the update of the items of the personal collection of deleted users, or nil
+// when they are kept.
+func (u *userBuilder) personalCollectionUpdate() *client.CollectionItemUpdate {
+	switch {
+	case u.deprovision.transferCollectionID != "":
+		return &client.CollectionItemUpdate{CollectionID: u.deprovision.transferCollectionID}
+	case u.deprovision.archivePersonalCollection:
+		return &client.CollectionItemUpdate{Archived: true}
+	default:
+		return nil
+	}
+}
+
+func parseIntoUserResource(user *client.User) (*v2.Resource, error) {
+	profile := map[string]interface{}{
+		"first_name": user.FirstName,
+		"last_name":  user.LastName,
+	}
+
+	if len(user.LoginAttributes) > 0 {
+		profile["login_attributes"] = user.LoginAttributes
+	}
+
+	traitOptions := []resourceSdk.UserTraitOption{
+		resourceSdk.WithEmail(user.Email, true),
+		resourceSdk.WithUserLogin(user.Email),
+		resourceSdk.WithUserProfile(profile),
+	}
+
+	if user.LastLogin != nil {
+		traitOptions = append(traitOptions, resourceSdk.WithLastLogin(*user.LastLogin))
+	}
+
+	if user.IsActive {
+		traitOptions = append(traitOptions, resourceSdk.WithStatus(v2.UserTrait_Status_STATUS_ENABLED))
+	} else {
+		traitOptions = append(traitOptions, resourceSdk.WithStatus(v2.UserTrait_Status_STATUS_DISABLED))
+	}
+
+	return resourceSdk.NewUserResource(
+		fmt.Sprintf("%s %s", user.FirstName, user.LastName),
+		baseConnector.UserResourceType,
+		user.ID,
+		traitOptions,
+	)
+}
+
+func newUserBuilder(client client.ClientService, deprovision deprovisionOptions) *userBuilder {
+	return &userBuilder{
+		client:      client,
+		deprovision: deprovision,
+	}
+}
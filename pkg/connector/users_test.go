@@ -0,0 +1,322 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/conductorone/baton-metabase-v056/pkg/client"
+	baseConnector "github.com/conductorone/baton-metabase/pkg/connector"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func newTestUserBuilder() (*userBuilder, *client.MockService) {
+	mockClient := &client.MockService{}
+	builder := newUserBuilder(mockClient, deprovisionOptions{})
+	return builder, mockClient
+}
+
+func TestUserList(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should list a page of users", func(t *testing.T) {
+		builder, mockClient := newTestUserBuilder()
+		mockClient.ListUsersFunc = func(ctx context.Context, opts client.PageOptions) ([]*client.User, int, *v2.RateLimitDescription, error) {
+			require.Equal(t, client.PageOptions{Limit: resourcePageSize}, opts)
+			return []*client.User{
+				{ID: 1, Email: "jane@example.com", FirstName: "Jane", LastName: "Doe", IsActive: true},
+				{ID: 2, Email: "john@example.com", FirstName: "John", LastName: "Doe"},
+			}, 150, nil, nil
+		}
+
+		resources, results, err := builder.List(ctx, nil, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.NoError(t, err)
+		nextPageToken := results.NextPageToken
+		require.Equal(t, "100", nextPageToken)
+		require.Len(t, resources, 2)
+		require.Equal(t, baseConnector.UserResourceType.Id, resources[0].Id.ResourceType)
+		require.Equal(t, "Jane Doe", resources[0].DisplayName)
+
+		userTrait, err := resourceSdk.GetUserTrait(resources[1])
+		require.NoError(t, err)
+		require.Equal(t, v2.UserTrait_Status_STATUS_DISABLED, userTrait.Status.Status)
+		require.Equal(t, "john@example.com", userTrait.Login)
+	})
+
+	t.Run("should add login attributes to the profile", func(t *testing.T) {
+		builder, mockClient := newTestUserBuilder()
+		mockClient.ListUsersFunc = func(ctx context.Context, opts client.PageOptions) ([]*client.User, int, *v2.RateLimitDescription, error) {
+			return []*client.User{
+				{ID: 1, Email: "jane@example.com", LoginAttributes: map[string]interface{}{"tenant_id": "42"}},
+			}, 1, nil, nil
+		}
+
+		resources, _, err := builder.List(ctx, nil, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.NoError(t, err)
+
+		userTrait, err := resourceSdk.GetUserTrait(resources[0])
+		require.NoError(t, err)
+		attributes := userTrait.Profile.Fields["login_attributes"].GetStructValue()
+		require.Equal(t, "42", attributes.Fields["tenant_id"].GetStringValue())
+	})
+
+	t.Run("should return error if ListUsers fails", func(t *testing.T) {
+		builder, mockClient := newTestUserBuilder()
+		mockClient.ListUsersFunc = func(ctx context.Context, opts client.PageOptions) ([]*client.User, int, *v2.RateLimitDescription, error) {
+			return nil, 0, nil, fmt.Errorf("API error")
+		}
+
+		_, _, err := builder.List(ctx, nil, resourceSdk.SyncOpAttrs{SyncID: testSyncID})
+		require.Error(t, err)
+	})
+}
+
+func TestUserCreateAccount(t *testing.T) {
+	ctx := context.Background()
+
+	newAccountInfo := func(t *testing.T, profile map[string]interface{}) *v2.AccountInfo {
+		s, err := structpb.NewStruct(profile)
+		require.NoError(t, err)
+		return &v2.AccountInfo{Profile: s}
+	}
+
+	t.Run("should create the user with a random password", func(t *testing.T) {
+		builder, mockClient := newTestUserBuilder()
+		var created *client.CreateUserRequest
+		mockClient.CreateUserFunc = func(ctx context.Context, request *client.CreateUserRequest) (*client.User, *v2.RateLimitDescription, error) {
+			created = request
+			return &client.User{ID: 9, Email: request.Email, FirstName: request.FirstName, LastName: request.LastName, IsActive: true}, nil, nil
+		}
+
+		resp, plaintexts, _, err := builder.CreateAccount(ctx, newAccountInfo(t, map[string]interface{}{
+			"email":      "jane@example.com",
+			"first_name": "Jane",
+			"last_name":  "Doe",
+		}), nil)
+		require.NoError(t, err)
+		require.Equal(t, "jane@example.com", created.Email)
+		require.Len(t, plaintexts, 1)
+		require.Equal(t, created.Password, string(plaintexts[0].Bytes))
+
+		result, ok := resp.(*v2.CreateAccountResponse_SuccessResult)
+		require.True(t, ok)
+		require.Equal(t, "9", result.Resource.Id.Resource)
+	})
+
+	t.Run("should return error if a required field is missing", func(t *testing.T) {
+		builder, _ := newTestUserBuilder()
+
+		_, _, _, err := builder.CreateAccount(ctx, newAccountInfo(t, map[string]interface{}{
+			"email":      "jane@example.com",
+			"first_name": "Jane",
+		}), nil)
+		require.Error(t, err)
+	})
+}
+
+func TestUserDelete(t *testing.T) {
+	ctx := context.Background()
+	userID := &v2.ResourceId{ResourceType: baseConnector.UserResourceType.Id, Resource: "7"}
+	personalCollectionID := 9
+
+	newDeleteTestClient := func(mockClient *client.MockService, deactivated *bool) {
+		mockClient.GetUserFunc = func(ctx context.Context, userID string) (*client.User, *v2.RateLimitDescription, error) {
+			return &client.User{ID: 7, IsActive: true, PersonalCollectionID: &personalCollectionID}, nil, nil
+		}
+		mockClient.UpdateUserActiveStatusFunc = func(ctx context.Context, userID string, active bool) (*client.User, *v2.RateLimitDescription, error) {
+			require.False(t, active)
+			*deactivated = true
+			return &client.User{ID: 7}, nil, nil
+		}
+	}
+
+	t.Run("should only deactivate the user by default", func(t *testing.T) {
+		builder, mockClient := newTestUserBuilder()
+		var deactivated bool
+		newDeleteTestClient(mockClient, &deactivated)
+
+		_, err := builder.Delete(ctx, userID, nil)
+		require.NoError(t, err)
+		require.True(t, deactivated)
+	})
+
+	t.Run("should remove memberships except All Users", func(t *testing.T) {
+		builder, mockClient := newTestUserBuilder()
+		builder.deprovision.removeMemberships = true
+		var deactivated bool
+		newDeleteTestClient(mockClient, &deactivated)
+		mockClient.ListMembershipsFunc = func(ctx context.Context) (map[string][]*client.Membership, *v2.RateLimitDescription, error) {
+			return map[string][]*client.Membership{
+				"7": {
+					{MembershipID: 1, GroupID: client.AllUsersGroupID, UserID: 7},
+					{MembershipID: 2, GroupID: 3, UserID: 7},
+				},
+			}, nil, nil
+		}
+		var removed []string
+		mockClient.RemoveUserFromGroupFunc = func(ctx context.Context, membershipID string) (*v2.RateLimitDescription, error) {
+			removed = append(removed, membershipID)
+			return nil, nil
+		}
+
+		_, err := builder.Delete(ctx, userID, nil)
+		require.NoError(t, err)
+		require.Equal(t, []string{"2"}, removed)
+		require.True(t, deactivated)
+	})
+
+	t.Run("should transfer the personal collection", func(t *testing.T) {
+		builder, mockClient := newTestUserBuilder()
+		builder.deprovision.transferCollectionID = "5"
+		var deactivated bool
+		newDeleteTestClient(mockClient, &deactivated)
+		mockClient.ListCollectionItemsFunc = func(ctx context.Context, collectionID int) ([]*client.CollectionItem, *v2.RateLimitDescription, error) {
+			require.Equal(t, personalCollectionID, collectionID)
+			return []*client.CollectionItem{
+				{ID: 12, Model: "card"},
+				{ID: 3, Model: "dashboard"},
+				{ID: 1, Model: "pulse"},
+			}, nil, nil
+		}
+		var moved []string
+		mockClient.UpdateCollectionItemFunc = func(ctx context.Context, item *client.CollectionItem, update *client.CollectionItemUpdate) (*v2.RateLimitDescription, error) {
+			if item.Model == "pulse" {
+				return nil, client.ErrUnsupportedItem
+			}
+			require.False(t, update.Archived)
+			require.Equal(t, client.CollectionID("5"), update.CollectionID)
+			moved = append(moved, fmt.Sprintf("%s:%d", item.Model, item.ID))
+			return nil, nil
+		}
+
+		_, err := builder.Delete(ctx, userID, nil)
+		require.NoError(t, err)
+		require.Equal(t, []string{"card:12", "dashboard:3"}, moved)
+		require.True(t, deactivated)
+	})
+
+	t.Run("should archive the personal collection", func(t *testing.T) {
+		builder, mockClient := newTestUserBuilder()
+		builder.deprovision.archivePersonalCollection = true
+		var deactivated bool
+		newDeleteTestClient(mockClient, &deactivated)
+		mockClient.ListCollectionItemsFunc = func(ctx context.Context, collectionID int) ([]*client.CollectionItem, *v2.RateLimitDescription, error) {
+			return []*client.CollectionItem{{ID: 8, Model: "collection"}}, nil, nil
+		}
+		var archived bool
+		mockClient.UpdateCollectionItemFunc = func(ctx context.Context, item *client.CollectionItem, update *client.CollectionItemUpdate) (*v2.RateLimitDescription, error) {
+			archived = update.Archived && update.CollectionID == ""
+			return nil, nil
+		}
+
+		_, err := builder.Delete(ctx, userID, nil)
+		require.NoError(t, err)
+		require.True(t, archived)
+		require.True(t, deactivated)
+	})
+
+	t.Run("should not deactivate an inactive user", func(t *testing.T) {
+		builder, mockClient := newTestUserBuilder()
+		mockClient.GetUserFunc = func(ctx context.Context, userID string) (*client.User, *v2.RateLimitDescription, error) {
+			return &client.User{ID: 7}, nil, nil
+		}
+
+		_, err := builder.Delete(ctx, userID, nil)
+		require.NoError(t, err)
+	})
+}
+
+func TestUpdateUser(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should only update the fields that are set", func(t *testing.T) {
+		mockClient := newTestClient()
+		var update *client.UserUpdate
+		mockClient.UpdateUserFunc = func(ctx context.Context, userID string, u *client.UserUpdate) (*client.User, *v2.RateLimitDescription, error) {
+			require.Equal(t, "7", userID)
+			update = u
+			return &client.User{ID: 7}, nil, nil
+		}
+		c := &Connector{v056Client: mockClient}
+
+		resp, _, err := c.UpdateUser(ctx, &structpb.Struct{Fields: map[string]*structpb.Value{
+			"userId":    structpb.NewStringValue("7"),
+			"firstName": structpb.NewStringValue("Janet"),
+			"locale":    structpb.NewStringValue("pt_BR"),
+		}})
+		require.NoError(t, err)
+		require.True(t, resp.Fields["success"].GetBoolValue())
+		require.Equal(t, "Janet", *update.FirstName)
+		require.Equal(t, "pt_BR", *update.Locale)
+		require.Nil(t, update.LastName)
+		require.Nil(t, update.Email)
+	})
+
+	t.Run("should require a field to update", func(t *testing.T) {
+		c := &Connector{v056Client: newTestClient()}
+
+		_, _, err := c.UpdateUser(ctx, &structpb.Struct{Fields: map[string]*structpb.Value{
+			"userId": structpb.NewStringValue("7"),
+		}})
+		require.Error(t, err)
+	})
+}
+
+func TestSetLoginAttributes(t *testing.T) {
+	ctx := context.Background()
+
+	newArgs := func(t *testing.T, attributes map[string]interface{}, mode string) *structpb.Struct {
+		attributesStruct, err := structpb.NewStruct(attributes)
+		require.NoError(t, err)
+		args := &structpb.Struct{Fields: map[string]*structpb.Value{
+			"userId":     structpb.NewStringValue("7"),
+			"attributes": structpb.NewStructValue(attributesStruct),
+		}}
+		if mode != "" {
+			args.Fields["mode"] = structpb.NewStringValue(mode)
+		}
+		return args
+	}
+
+	newLoginAttributesTestClient := func(updated *map[string]interface{}) *client.MockService {
+		mockClient := newTestClient()
+		mockClient.GetUserFunc = func(ctx context.Context, userID string) (*client.User, *v2.RateLimitDescription, error) {
+			return &client.User{ID: 7, LoginAttributes: map[string]interface{}{"tenant_id": "1", "region": "eu"}}, nil, nil
+		}
+		mockClient.UpdateUserFunc = func(ctx context.Context, userID string, u *client.UserUpdate) (*client.User, *v2.RateLimitDescription, error) {
+			*updated = *u.LoginAttributes
+			return &client.User{ID: 7, LoginAttributes: *u.LoginAttributes}, nil, nil
+		}
+		return mockClient
+	}
+
+	t.Run("should merge the attributes by default", func(t *testing.T) {
+		var updated map[string]interface{}
+		c := &Connector{v056Client: newLoginAttributesTestClient(&updated)}
+
+		resp, _, err := c.SetLoginAttributes(ctx, newArgs(t, map[string]interface{}{"tenant_id": "2", "region": nil}, ""))
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{"tenant_id": "2"}, updated)
+		require.Equal(t, "2", resp.Fields["attributes"].GetStructValue().Fields["tenant_id"].GetStringValue())
+	})
+
+	t.Run("should replace the attributes", func(t *testing.T) {
+		var updated map[string]interface{}
+		c := &Connector{v056Client: newLoginAttributesTestClient(&updated)}
+
+		_, _, err := c.SetLoginAttributes(ctx, newArgs(t, map[string]interface{}{"department": "sales"}, loginAttributesReplace))
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{"department": "sales"}, updated)
+	})
+
+	t.Run("should reject an unknown mode", func(t *testing.T) {
+		var updated map[string]interface{}
+		c := &Connector{v056Client: newLoginAttributesTestClient(&updated)}
+
+		_, _, err := c.SetLoginAttributes(ctx, newArgs(t, map[string]interface{}{"tenant_id": "2"}, "append"))
+		require.Error(t, err)
+	})
+}